@@ -2,141 +2,341 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
-	"gopkg.in/yaml.v3"
 )
 
+// Config 配置快照，加载后不再修改，重载时整体替换为新的快照
 type Config struct {
-	Server struct {
-		Port int `yaml:"port"`
-		Cors struct {
-			AllowedOrigins []string `yaml:"allowed_origins"`
-			AllowedMethods []string `yaml:"allowed_methods"`
-			AllowedHeaders []string `yaml:"allowed_headers"`
-		} `yaml:"cors"`
-	} `yaml:"server"`
-
-	Database struct {
-		Driver   string `yaml:"driver"`
-		Host     string `yaml:"host"`
-		Port     int    `yaml:"port"`
-		Username string `yaml:"username"`
-		Password string `yaml:"password"`
-		DBName   string `yaml:"dbname"`
-		Params   string `yaml:"params"`
-	} `yaml:"database"`
-
-	Redis struct {
-		Mode    string        `yaml:"mode"`
-		Single  SingleConfig  `yaml:"single"`
-		Cluster ClusterConfig `yaml:"cluster"`
-	} `yaml:"redis"`
-
-	mu sync.RWMutex
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Redis    RedisConfig    `yaml:"redis"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Log      LogConfig      `yaml:"log"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+}
+
+// ServerConfig HTTP 服务配置，超时为零时使用默认值
+type ServerConfig struct {
+	Port              int           `yaml:"port" validate:"required,min=1,max=65535"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" validate:"min=0"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" validate:"min=0"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" validate:"min=0"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" validate:"min=0"`
+	// ShutdownTimeout 收到退出信号后等待处理中请求完成的最长时间
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" validate:"min=0"`
+	Cors            CorsConfig    `yaml:"cors"`
+}
+
+type CorsConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins" validate:"required,dive,cors_origin"`
+	AllowedMethods []string `yaml:"allowed_methods" validate:"required,dive,oneof=GET POST PUT PATCH DELETE HEAD OPTIONS"`
+	AllowedHeaders []string `yaml:"allowed_headers" validate:"dive,required"`
+}
+
+type DatabaseConfig struct {
+	Driver   string `yaml:"driver" validate:"required,oneof=mysql"`
+	Host     string `yaml:"host" validate:"required"`
+	Port     int    `yaml:"port" validate:"required,min=1,max=65535"`
+	Username string `yaml:"username" validate:"required"`
+	Password string `yaml:"password"`
+	DBName   string `yaml:"dbname" validate:"required"`
+	Params   string `yaml:"params"`
+}
+
+// AuthConfig 登录认证配置，TTL 为零时使用默认值（访问令牌 15 分钟，刷新令牌 7 天）
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret" validate:"required,min=32"`
+	Issuer          string        `yaml:"issuer"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" validate:"min=0"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" validate:"min=0"`
+	Admin           AdminConfig   `yaml:"admin"`
+}
+
+// LogConfig 日志配置，SlowQueryThreshold 为零时不记录慢查询
+type LogConfig struct {
+	Level              string        `yaml:"level" validate:"omitempty,oneof=debug info warn error"`
+	Format             string        `yaml:"format" validate:"omitempty,oneof=json text"`
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" validate:"min=0"`
+}
+
+// TracingConfig OpenTelemetry 追踪配置，Exporter 为空或 none 时不导出
+// Endpoint 为空时读取 OTEL_EXPORTER_OTLP_ENDPOINT，SampleRatio 未设置时全部采样，为 0 时不采样
+type TracingConfig struct {
+	Exporter    string   `yaml:"exporter" validate:"omitempty,oneof=none otlp stdout"`
+	Protocol    string   `yaml:"protocol" validate:"omitempty,oneof=grpc http"`
+	Endpoint    string   `yaml:"endpoint" validate:"omitempty,hostname_port"`
+	Insecure    bool     `yaml:"insecure"`
+	SampleRatio *float64 `yaml:"sample_ratio" validate:"omitempty,min=0,max=1"`
+	ServiceName string   `yaml:"service_name"`
+}
+
+// AdminConfig 初始管理员账号，邮箱不存在时在启动时创建
+type AdminConfig struct {
+	Name     string `yaml:"name" validate:"max=100"`
+	Email    string `yaml:"email" validate:"omitempty,email"`
+	Password string `yaml:"password" validate:"required_with=Email,omitempty,min=8,max=72"`
 }
 
 type RedisConfig struct {
-	Mode    string        `yaml:"mode"`
-	Single  SingleConfig  `yaml:"single"`
-	Cluster ClusterConfig `yaml:"cluster"`
+	Mode     string          `yaml:"mode" validate:"required,oneof=single cluster sentinel"`
+	Single   SingleConfig    `yaml:"single"`
+	Cluster  ClusterConfig   `yaml:"cluster"`
+	Sentinel SentinelConfig  `yaml:"sentinel"`
+	TLS      RedisTLSConfig  `yaml:"tls"`
+	Pool     RedisPoolConfig `yaml:"pool"`
+	Console  ConsoleConfig   `yaml:"console"`
 }
 
 type SingleConfig struct {
 	Host     string `yaml:"host"`
-	Port     int    `yaml:"port"`
+	Port     int    `yaml:"port" validate:"omitempty,min=1,max=65535"`
+	Username string `yaml:"username"`
 	Password string `yaml:"password"`
-	DB       int    `yaml:"db"`
+	DB       int    `yaml:"db" validate:"min=0"`
 }
 
 type ClusterConfig struct {
-	Addrs    []string `yaml:"addrs"`
+	Addrs    []string `yaml:"addrs" validate:"dive,hostname_port"`
+	Username string   `yaml:"username"`
 	Password string   `yaml:"password"`
 	ReadOnly bool     `yaml:"read_only"`
 }
 
+// SentinelConfig 哨兵模式配置
+// Username/Password 用于连接主节点，SentinelUsername/SentinelPassword 用于连接哨兵
+type SentinelConfig struct {
+	MasterName       string   `yaml:"master_name"`
+	Addrs            []string `yaml:"addrs" validate:"dive,hostname_port"`
+	Username         string   `yaml:"username"`
+	Password         string   `yaml:"password"`
+	SentinelUsername string   `yaml:"sentinel_username"`
+	SentinelPassword string   `yaml:"sentinel_password"`
+	DB               int      `yaml:"db" validate:"min=0"`
+}
+
+// RedisTLSConfig Redis TLS 配置，CertFile/KeyFile 用于双向认证
+type RedisTLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file" validate:"omitempty,file"`
+	CertFile           string `yaml:"cert_file" validate:"required_with=KeyFile,omitempty,file"`
+	KeyFile            string `yaml:"key_file" validate:"required_with=CertFile,omitempty,file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// RedisPoolConfig 连接池与超时配置，零值表示使用 go-redis 默认值
+// 负值沿用 go-redis 的含义：超时为 -1 表示不超时，MaxRetries 为 -1 表示不重试
+type RedisPoolConfig struct {
+	PoolSize        int           `yaml:"pool_size" validate:"min=0"`
+	MinIdleConns    int           `yaml:"min_idle_conns" validate:"min=0"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" validate:"min=0"`
+	PoolTimeout     time.Duration `yaml:"pool_timeout" validate:"min=0"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" validate:"min=-1ns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" validate:"min=-1ns"`
+	DialTimeout     time.Duration `yaml:"dial_timeout" validate:"min=0"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" validate:"min=-2ns"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" validate:"min=-2ns"`
+	MaxRetries      int           `yaml:"max_retries" validate:"min=-1"`
+}
+
+// ConsoleConfig Redis 命令控制台的允许/禁止列表
+// 条目可以是命令名（如 FLUSHALL），也可以是命令加子命令（如 CONFIG SET）
+type ConsoleConfig struct {
+	AllowedCommands []string `yaml:"allowed_commands" validate:"dive,required"`
+	DeniedCommands  []string `yaml:"denied_commands" validate:"dive,required"`
+}
+
 var (
-	cfg  *Config
-	once sync.Once
+	current atomic.Pointer[Config]
+	once    sync.Once
+
+	watcherMu     sync.Mutex
+	configWatcher *fsnotify.Watcher
 )
 
-// GetConfig 返回配置单例
+// GetConfig 返回当前配置快照，首次调用时加载配置并开始监听文件变化
+// 返回的是快照的副本，调用方修改它不会影响其它请求；需要读取多个相关字段时使用同一个副本
 func GetConfig() *Config {
 	once.Do(func() {
-		cfg = &Config{}
-		if err := cfg.Load(); err != nil {
-			log.Fatalf("Failed to load config: %v", err)
+		if _, err := Load(); err != nil {
+			slog.Error("Failed to load config", "error", err)
+			os.Exit(1)
 		}
-		go cfg.Watch()
+		if files, err := Files(); err == nil {
+			recordLoaded(files)
+		}
+		go Watch()
 	})
-	return cfg
+	return current.Load().Clone()
+}
+
+// Clone 深拷贝配置，新增切片、map 或指针字段时需要在这里一并复制
+func (c *Config) Clone() *Config {
+	if c == nil {
+		return nil
+	}
+	n := *c
+	n.Server.Cors.AllowedOrigins = slices.Clone(c.Server.Cors.AllowedOrigins)
+	n.Server.Cors.AllowedMethods = slices.Clone(c.Server.Cors.AllowedMethods)
+	n.Server.Cors.AllowedHeaders = slices.Clone(c.Server.Cors.AllowedHeaders)
+	n.Redis.Cluster.Addrs = slices.Clone(c.Redis.Cluster.Addrs)
+	n.Redis.Sentinel.Addrs = slices.Clone(c.Redis.Sentinel.Addrs)
+	n.Redis.Console.AllowedCommands = slices.Clone(c.Redis.Console.AllowedCommands)
+	n.Redis.Console.DeniedCommands = slices.Clone(c.Redis.Console.DeniedCommands)
+	if c.Tracing.SampleRatio != nil {
+		ratio := *c.Tracing.SampleRatio
+		n.Tracing.SampleRatio = &ratio
+	}
+	return &n
 }
 
-// Load 加载配置文件
-func (c *Config) Load() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// Server 当前的服务配置
+func Server() ServerConfig {
+	return GetConfig().Server
+}
+
+// Database 当前的数据库配置
+func Database() DatabaseConfig {
+	return GetConfig().Database
+}
+
+// Redis 当前的 Redis 配置
+func Redis() RedisConfig {
+	return GetConfig().Redis
+}
+
+// Auth 当前的登录认证配置
+func Auth() AuthConfig {
+	return GetConfig().Auth
+}
 
-	data, err := os.ReadFile("config/config.yaml")
+// Log 当前的日志配置
+func Log() LogConfig {
+	return GetConfig().Log
+}
+
+// Tracing 当前的追踪配置
+func Tracing() TracingConfig {
+	return GetConfig().Tracing
+}
+
+// Load 加载并校验配置文件，成功后替换当前配置快照并返回它的副本；校验失败时保留当前配置
+func Load() (*Config, error) {
+	files, err := Files()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	n, err := loadConfig(files)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.Validate(); err != nil {
+		return nil, err
 	}
 
-	return yaml.Unmarshal(data, c)
+	current.Store(n)
+	return n.Clone(), nil
 }
 
-// Watch 监听配置文件变化
-func (c *Config) Watch() {
+// Watch 监听配置文件变化（包括环境覆盖文件）
+// 监听所在目录而不是文件本身，编辑器以替换文件的方式保存时也能触发重载
+func Watch() {
+	files, err := Files()
+	if err != nil {
+		slog.Error("Failed to resolve config files", "error", err)
+		return
+	}
+
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		log.Printf("Failed to create watcher: %v", err)
+		slog.Error("Failed to create watcher", "error", err)
 		return
 	}
-	defer watcher.Close()
+
+	watched := make(map[string]bool, len(files))
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		watched[filepath.Clean(file)] = true
+		dirs[filepath.Dir(file)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			slog.Error("Failed to watch config dir", "dir", dir, "error", err)
+		}
+	}
+
+	watcherMu.Lock()
+	configWatcher = watcher
+	watcherMu.Unlock()
 
 	go func() {
+		defer watcher.Close()
+
+		// 一次保存可能产生多个事件，合并为一次重载
+		var debounce *time.Timer
+		defer func() {
+			if debounce != nil {
+				debounce.Stop()
+			}
+		}()
 		for {
 			select {
 			case event, ok := <-watcher.Events:
 				if !ok {
 					return
 				}
-				if event.Op&fsnotify.Write == fsnotify.Write {
-					log.Println("Config file modified, reloading...")
-					time.Sleep(100 * time.Millisecond) // 等待文件写入完成
-					if err := c.Load(); err != nil {
-						log.Printf("Failed to reload config: %v", err)
-					}
+				if !watched[filepath.Clean(event.Name)] || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+					continue
+				}
+				if debounce == nil {
+					debounce = time.AfterFunc(200*time.Millisecond, reload)
+				} else {
+					debounce.Reset(200 * time.Millisecond)
 				}
 			case err, ok := <-watcher.Errors:
 				if !ok {
 					return
 				}
-				log.Printf("Watcher error: %v", err)
+				slog.Error("Watcher error", "error", err)
 			}
 		}
 	}()
+}
+
+// StopWatch 停止监听配置文件，用于退出前释放 watcher
+func StopWatch() error {
+	watcherMu.Lock()
+	defer watcherMu.Unlock()
 
-	if err := watcher.Add("config/config.yaml"); err != nil {
-		log.Printf("Failed to watch config file: %v", err)
+	if configWatcher == nil {
+		return nil
 	}
+	err := configWatcher.Close()
+	configWatcher = nil
+	return err
 }
 
 // GetDSN 获取数据库连接字符串
 func (c *Config) GetDSN() string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	return c.Database.DSN()
+}
 
+// DSN 获取数据库连接字符串
+func (d DatabaseConfig) DSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s",
-		c.Database.Username,
-		c.Database.Password,
-		c.Database.Host,
-		c.Database.Port,
-		c.Database.DBName,
-		c.Database.Params,
+		d.Username,
+		d.Password,
+		d.Host,
+		d.Port,
+		d.DBName,
+		d.Params,
 	)
 }
@@ -1,23 +1,218 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
 
+	"github.com/wgcoder2024/go-web/backend/logging"
+	"github.com/wgcoder2024/go-web/backend/metrics"
 	"github.com/wgcoder2024/go-web/backend/models"
+	"github.com/wgcoder2024/go-web/backend/tracing"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+var db atomic.Pointer[gorm.DB]
+
+// GetDB 返回当前数据库连接，配置重载后会切换到新的连接池
+func GetDB() *gorm.DB {
+	return db.Load()
+}
 
 func InitDB() {
-	var err error
 	cfg := GetConfig()
-	DB, err = gorm.Open(mysql.Open(cfg.GetDSN()), &gorm.Config{})
+	conn, err := openDB(cfg.GetDSN())
+	if err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	if err := setupDB(conn, cfg.Auth.Admin); err != nil {
+		slog.Error("Failed to initialize database", "error", err)
+		os.Exit(1)
+	}
+	db.Store(conn)
+
+	OnReload("database", reloadDB)
+}
+
+// CloseDB 关闭当前数据库连接池
+func CloseDB() error {
+	conn := db.Load()
+	if conn == nil {
+		return nil
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func openDB(dsn string) (*gorm.DB, error) {
+	conn, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		// 将唯一索引冲突等驱动错误转换为 gorm.ErrDuplicatedKey
+		TranslateError: true,
+		Logger:         logging.GormLogger{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Use(metrics.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := conn.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// appModels 应用自身的表（账号、令牌、授权、审计等），与业务表在同一个库中
+// 表管理和 SQL 接口只允许管理员访问这些表，避免读取密码哈希或改写授权、审计记录
+var appModels = []interface{}{
+	&models.User{},
+	&models.UserAudit{},
+	&models.RefreshToken{},
+	&models.Role{},
+	&models.RolePermission{},
+	&models.UserRole{},
+	&models.AuditLog{},
+	&models.RedisScript{},
+}
+
+var appTables atomic.Pointer[map[string]bool]
+
+// IsAppTable 表是否属于应用自身，不区分大小写
+func IsAppTable(name string) bool {
+	if tables := appTables.Load(); tables != nil {
+		return (*tables)[strings.ToLower(name)]
+	}
+	return false
+}
+
+// setupDB 自动迁移表结构并创建内置角色和初始管理员
+func setupDB(conn *gorm.DB, admin AdminConfig) error {
+	if err := conn.AutoMigrate(appModels...); err != nil {
+		return err
+	}
+
+	tables := make(map[string]bool, len(appModels))
+	for _, model := range appModels {
+		stmt := &gorm.Statement{DB: conn}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		tables[strings.ToLower(stmt.Schema.Table)] = true
+	}
+	appTables.Store(&tables)
+
+	if err := seedRoles(conn); err != nil {
+		return fmt.Errorf("create default roles: %w", err)
+	}
+	if err := seedAdmin(conn, admin); err != nil {
+		return fmt.Errorf("create admin user: %w", err)
+	}
+	return nil
+}
+
+// reloadDB 数据库配置变化时连接新库，成功后替换并延迟关闭旧连接池
+func reloadDB(old, new *Config) error {
+	if old.Database == new.Database {
+		return nil
+	}
+
+	conn, err := openDB(new.GetDSN())
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return err
+	}
+	if err := setupDB(conn, new.Auth.Admin); err != nil {
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+		return err
+	}
+
+	if prev := db.Swap(conn); prev != nil {
+		if sqlDB, err := prev.DB(); err == nil {
+			closeAfterDrain("database", sqlDB)
+		}
+	}
+	slog.Info("Database reconnected", "host", new.Database.Host, "port", new.Database.Port, "dbname", new.Database.DBName)
+	return nil
+}
+
+// defaultRoles 内置角色，启动时创建缺少的角色，已存在的角色不做修改
+var defaultRoles = []struct {
+	name, description string
+	permissions       []string
+}{
+	{"analyst", "只读：查看用户、表数据和 Redis", []string{"users.read", "tables.read", "redis.read"}},
+	{"developer", "读写数据和 Redis 键", []string{"users.read", "tables.read", "redis.read", "redis.write"}},
+	{"dba", "表结构变更、Redis 删除和运维", []string{"users.read", "tables.*", "redis.*", "audit.read", "config.read"}},
+	{"admin", "全部权限", []string{"*"}},
+}
+
+func seedRoles(conn *gorm.DB) error {
+	for _, r := range defaultRoles {
+		var count int64
+		if err := conn.Model(&models.Role{}).Where("name = ?", r.name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		role := models.Role{Name: r.name, Description: r.description}
+		for _, p := range r.permissions {
+			role.Permissions = append(role.Permissions, models.RolePermission{Permission: p})
+		}
+		if err := conn.Create(&role).Error; err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// 自动迁移
-	DB.AutoMigrate(&models.User{})
+// seedAdmin 创建初始管理员，邮箱已存在（包括已删除）时不修改账号
+// 还没有任何用户拥有 admin 角色时，将 admin 角色授予初始管理员
+func seedAdmin(conn *gorm.DB, admin AdminConfig) error {
+	if admin.Email == "" || admin.Password == "" {
+		return nil
+	}
+
+	var user models.User
+	err := conn.Unscoped().Where("email = ?", admin.Email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		hash, err := bcrypt.GenerateFromPassword([]byte(admin.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		user = models.User{Name: admin.Name, Email: admin.Email, PasswordHash: string(hash)}
+		if user.Name == "" {
+			user.Name = "admin"
+		}
+		if err := conn.Create(&user).Error; err != nil {
+			return err
+		}
+		slog.Info("Created admin user", "email", admin.Email)
+	} else if err != nil {
+		return err
+	}
+
+	var role models.Role
+	if err := conn.Where("name = ?", "admin").First(&role).Error; err != nil {
+		return err
+	}
+	var count int64
+	if err := conn.Model(&models.UserRole{}).Where("role_id = ?", role.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return conn.Create(&models.UserRole{UserID: user.ID, RoleID: role.ID}).Error
 }
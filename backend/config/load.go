@@ -0,0 +1,234 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultConfigPath = "config/config.yaml"
+
+	// EnvPrefix 环境变量覆盖的前缀，如 GO_WEB_DATABASE_PASSWORD 覆盖 database.password
+	EnvPrefix = "GO_WEB_"
+)
+
+var (
+	configPath string
+	configEnv  string
+)
+
+// SetPath 设置配置文件路径和环境名，需在 GetConfig 之前调用
+// path 为空时依次使用 GO_WEB_CONFIG 和 config/config.yaml，env 为空时使用 GO_WEB_ENV
+func SetPath(path, env string) {
+	configPath = path
+	configEnv = env
+}
+
+// Files 返回实际加载的配置文件：基础文件和存在时的环境覆盖文件（config.<env>.yaml）
+func Files() ([]string, error) {
+	base, err := resolveConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	files := []string{base}
+
+	env := configEnv
+	if env == "" {
+		env = os.Getenv("GO_WEB_ENV")
+	}
+	if env != "" {
+		ext := filepath.Ext(base)
+		overlay := strings.TrimSuffix(base, ext) + "." + env + ext
+		if _, err := os.Stat(overlay); err == nil {
+			files = append(files, overlay)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// resolveConfigPath 未指定路径时先在工作目录查找，再到可执行文件所在目录查找
+func resolveConfigPath() (string, error) {
+	if configPath != "" {
+		return configPath, nil
+	}
+	if path := os.Getenv("GO_WEB_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	if _, err := os.Stat(defaultConfigPath); err == nil {
+		return defaultConfigPath, nil
+	}
+	if exe, err := os.Executable(); err == nil {
+		path := filepath.Join(filepath.Dir(exe), defaultConfigPath)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return defaultConfigPath, nil
+}
+
+// loadConfig 按顺序解析配置文件（后面的覆盖前面的），再应用环境变量覆盖，最后解密 enc: 值并读取 file: 引用
+func loadConfig(files []string) (*Config, error) {
+	c := &Config{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if err := interpolateEnv(&doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if err := doc.Decode(c); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	if err := applyEnvOverrides(reflect.ValueOf(c).Elem(), EnvPrefix); err != nil {
+		return nil, err
+	}
+	if err := resolveSecrets(reflect.ValueOf(c).Elem(), "", &secretResolver{}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolateEnv 替换标量值中的 ${VAR} 和 ${VAR:-default}，注释不受影响
+// 未设置且没有默认值的变量视为错误
+func interpolateEnv(doc *yaml.Node) error {
+	var missing []string
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode && envRefPattern.MatchString(n.Value) {
+			n.Value = envRefPattern.ReplaceAllStringFunc(n.Value, func(ref string) string {
+				m := envRefPattern.FindStringSubmatch(ref)
+				if v, ok := os.LookupEnv(m[1]); ok {
+					return v
+				}
+				if m[2] != "" {
+					return m[3]
+				}
+				missing = append(missing, m[1])
+				return ref
+			})
+			// 未加引号的值按替换后的内容重新推断类型，如 port: ${PORT}
+			if n.Style == 0 {
+				n.Tag = ""
+			}
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	if len(missing) > 0 {
+		return fmt.Errorf("environment variables not set: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// applyEnvOverrides 按 yaml 标签遍历配置，存在对应环境变量时覆盖字段
+// 变量名为前缀加上大写的字段路径，如 GO_WEB_REDIS_SINGLE_HOST；切片使用逗号分隔
+func applyEnvOverrides(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := prefix + strings.ToUpper(name)
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			if err := applyEnvOverrides(fv, key+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		if err := setConfigValue(fv, raw); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func setConfigValue(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := setConfigValue(elem.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
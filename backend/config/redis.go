@@ -2,37 +2,240 @@ package config
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/wgcoder2024/go-web/backend/logging"
+	"github.com/wgcoder2024/go-web/backend/metrics"
+	"github.com/wgcoder2024/go-web/backend/tracing"
 )
 
-var (
-	RDB redis.UniversalClient
-)
+var rdb atomic.Pointer[redisHandle]
+
+// redisHandle 记录客户端的长期使用者（如键分析任务），替换后等所有使用者释放才关闭
+type redisHandle struct {
+	client redis.UniversalClient
+
+	mu      sync.Mutex
+	leases  int
+	retired bool
+}
+
+func newRedisHandle(client redis.UniversalClient) *redisHandle {
+	return &redisHandle{client: client}
+}
+
+// acquire 增加一个使用者，客户端已关闭时返回 false
+func (h *redisHandle) acquire() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.retired && h.leases == 0 {
+		return false
+	}
+	h.leases++
+	return true
+}
+
+// release 释放一个使用者，客户端已被替换且没有其他使用者时关闭
+func (h *redisHandle) release() {
+	h.mu.Lock()
+	h.leases--
+	closeNow := h.retired && h.leases == 0
+	h.mu.Unlock()
+
+	if closeNow {
+		if err := h.client.Close(); err != nil {
+			slog.Error("Failed to close old pool", "pool", "redis", "error", err)
+		}
+	}
+}
+
+// Close 标记客户端已被替换，没有使用者时立即关闭，否则由最后一个使用者关闭
+func (h *redisHandle) Close() error {
+	h.mu.Lock()
+	h.retired = true
+	closeNow := h.leases == 0
+	h.mu.Unlock()
 
-func InitRedis() {
+	if closeNow {
+		return h.client.Close()
+	}
+	return nil
+}
+
+// GetRDB 返回当前 Redis 客户端，配置重载后会切换到新的客户端
+// 旧客户端在替换 poolDrainDelay 后关闭，运行时间更长的操作应使用 AcquireRDB
+func GetRDB() redis.UniversalClient {
+	if h := rdb.Load(); h != nil {
+		return h.client
+	}
+	return nil
+}
+
+// AcquireRDB 返回当前 Redis 客户端和释放函数，释放之前即使配置重载也不会关闭该客户端
+// 用于键分析、导出等可能超过 poolDrainDelay 的操作
+func AcquireRDB() (redis.UniversalClient, func()) {
+	for {
+		h := rdb.Load()
+		if h == nil {
+			return nil, func() {}
+		}
+		if h.acquire() {
+			var once sync.Once
+			return h.client, func() { once.Do(h.release) }
+		}
+		// 刚被替换并关闭，重新读取新客户端
+	}
+}
+
+// InitRedis 创建 Redis 客户端并注册配置重载回调，连接失败时返回错误，由调用方释放已打开的资源后退出
+func InitRedis() error {
 	cfg := GetConfig()
 
-	if cfg.Redis.Mode == "cluster" {
+	client, err := connectRedis(cfg.Redis)
+	if err != nil {
+		return err
+	}
+	rdb.Store(newRedisHandle(client))
+
+	OnReload("redis", reloadRedis)
+	return nil
+}
+
+// CloseRedis 关闭当前 Redis 客户端
+func CloseRedis() error {
+	if h := rdb.Load(); h != nil {
+		return h.client.Close()
+	}
+	return nil
+}
+
+// connectRedis 创建客户端并测试连接，失败时关闭客户端
+func connectRedis(rc RedisConfig) (redis.UniversalClient, error) {
+	client, err := newRedisClient(rc)
+	if err != nil {
+		return nil, err
+	}
+	client.AddHook(metrics.RedisHook{})
+	client.AddHook(logging.RedisHook{})
+	client.AddHook(tracing.RedisHook{})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	return client, nil
+}
+
+// reloadRedis 连接配置变化时创建新客户端，成功后替换并延迟关闭旧客户端
+// 命令控制台的允许/禁止列表每次请求时读取，不需要重建客户端
+func reloadRedis(old, new *Config) error {
+	prev, next := old.Redis, new.Redis
+	prev.Console, next.Console = ConsoleConfig{}, ConsoleConfig{}
+	if reflect.DeepEqual(prev, next) {
+		return nil
+	}
+
+	client, err := connectRedis(new.Redis)
+	if err != nil {
+		return err
+	}
+	if prev := rdb.Swap(newRedisHandle(client)); prev != nil {
+		closeAfterDrain("redis", prev)
+	}
+	slog.Info("Redis reconnected", "mode", new.Redis.Mode)
+	return nil
+}
+
+// newRedisClient 按 mode 创建单机、集群或哨兵客户端
+func newRedisClient(rc RedisConfig) (redis.UniversalClient, error) {
+	tlsConfig, err := newRedisTLSConfig(rc.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	// 公共的连接池、超时和 TLS 选项
+	opts := &redis.UniversalOptions{
+		PoolSize:        rc.Pool.PoolSize,
+		MinIdleConns:    rc.Pool.MinIdleConns,
+		MaxIdleConns:    rc.Pool.MaxIdleConns,
+		PoolTimeout:     rc.Pool.PoolTimeout,
+		ConnMaxIdleTime: rc.Pool.ConnMaxIdleTime,
+		ConnMaxLifetime: rc.Pool.ConnMaxLifetime,
+		DialTimeout:     rc.Pool.DialTimeout,
+		ReadTimeout:     rc.Pool.ReadTimeout,
+		WriteTimeout:    rc.Pool.WriteTimeout,
+		MaxRetries:      rc.Pool.MaxRetries,
+		TLSConfig:       tlsConfig,
+	}
+
+	switch rc.Mode {
+	case "cluster":
 		// 集群模式
-		RDB = redis.NewClusterClient(&redis.ClusterOptions{
-			Addrs:    cfg.Redis.Cluster.Addrs,
-			Password: cfg.Redis.Cluster.Password,
-			ReadOnly: cfg.Redis.Cluster.ReadOnly,
-		})
-	} else {
+		opts.Addrs = rc.Cluster.Addrs
+		opts.Username = rc.Cluster.Username
+		opts.Password = rc.Cluster.Password
+		opts.ReadOnly = rc.Cluster.ReadOnly
+		return redis.NewClusterClient(opts.Cluster()), nil
+
+	case "sentinel":
+		// 哨兵模式
+		opts.MasterName = rc.Sentinel.MasterName
+		opts.Addrs = rc.Sentinel.Addrs
+		opts.Username = rc.Sentinel.Username
+		opts.Password = rc.Sentinel.Password
+		opts.SentinelUsername = rc.Sentinel.SentinelUsername
+		opts.SentinelPassword = rc.Sentinel.SentinelPassword
+		opts.DB = rc.Sentinel.DB
+		return redis.NewFailoverClient(opts.Failover()), nil
+
+	default:
 		// 单机模式
-		RDB = redis.NewClient(&redis.Options{
-			Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Single.Host, cfg.Redis.Single.Port),
-			Password: cfg.Redis.Single.Password,
-			DB:       cfg.Redis.Single.DB,
-		})
+		opts.Addrs = []string{fmt.Sprintf("%s:%d", rc.Single.Host, rc.Single.Port)}
+		opts.Username = rc.Single.Username
+		opts.Password = rc.Single.Password
+		opts.DB = rc.Single.DB
+		return redis.NewClient(opts.Simple()), nil
 	}
+}
 
-	// 测试连接
-	ctx := context.Background()
-	if err := RDB.Ping(ctx).Err(); err != nil {
-		panic(fmt.Sprintf("Redis connection failed: %v", err))
+// newRedisTLSConfig 根据配置加载 CA 和客户端证书，未启用时返回 nil
+func newRedisTLSConfig(tc RedisTLSConfig) (*tls.Config, error) {
+	if !tc.Enabled {
+		return nil, nil
 	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         tc.ServerName,
+		InsecureSkipVerify: tc.InsecureSkipVerify,
+	}
+
+	if tc.CAFile != "" {
+		pem, err := os.ReadFile(tc.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificates in redis CA file %s", tc.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if tc.CertFile != "" || tc.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
 }
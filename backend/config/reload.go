@@ -0,0 +1,119 @@
+package config
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/wgcoder2024/go-web/backend/metrics"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+// poolDrainDelay 替换连接后延迟关闭旧连接池，让已取得旧连接的请求执行完
+const poolDrainDelay = 30 * time.Second
+
+// ReloadFunc 配置重载后的回调，old 和 new 是重载前后的只读快照
+// 返回错误时组件应保留原有状态，错误会记录在重载状态中
+type ReloadFunc func(old, new *Config) error
+
+type subscriber struct {
+	name string
+	fn   ReloadFunc
+	// applied 是该组件最近一次成功应用的配置，应用失败时保持不变，下次重载会重试
+	applied *Config
+}
+
+var (
+	subMu       sync.Mutex
+	subscribers []*subscriber
+
+	reloadMu     sync.Mutex
+	reloadStatus models.ConfigReloadStatus
+)
+
+// OnReload 注册配置重载回调，按注册顺序依次执行
+func OnReload(name string, fn ReloadFunc) {
+	subMu.Lock()
+	defer subMu.Unlock()
+	subscribers = append(subscribers, &subscriber{name: name, fn: fn, applied: current.Load()})
+}
+
+// ReloadStatus 返回当前配置文件和最近一次重载的结果
+func ReloadStatus() models.ConfigReloadStatus {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	status := reloadStatus
+	status.Files = append([]string(nil), reloadStatus.Files...)
+	status.Components = append([]models.ConfigComponentStatus(nil), reloadStatus.Components...)
+	return status
+}
+
+// recordLoaded 记录启动时加载的配置
+func recordLoaded(files []string) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadStatus.Files = files
+	reloadStatus.LoadedAt = time.Now()
+	reloadStatus.Success = true
+	metrics.ConfigLoaded()
+}
+
+// reload 重新加载配置并通知订阅者，校验失败时保留当前配置且不通知
+// 订阅者收到的 old 是它上次成功应用的配置，而不是上一个快照，这样应用失败的组件在下次重载时会重新比较并重试
+func reload() {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	now := time.Now()
+	reloadStatus.LastReload = &now
+	reloadStatus.Reloads++
+
+	next, err := Load()
+	if err != nil {
+		slog.Error("Failed to reload config, keeping previous config", "error", err)
+		reloadStatus.Success = false
+		reloadStatus.Error = err.Error()
+		reloadStatus.Failures++
+		metrics.ConfigReloaded(false)
+		return
+	}
+	metrics.ConfigReloaded(true)
+	if files, err := Files(); err == nil {
+		reloadStatus.Files = files
+	}
+	reloadStatus.LoadedAt = now
+	reloadStatus.Success = true
+	reloadStatus.Error = ""
+
+	subMu.Lock()
+	subs := append([]*subscriber(nil), subscribers...)
+	subMu.Unlock()
+
+	reloadStatus.Components = make([]models.ConfigComponentStatus, 0, len(subs))
+	for _, sub := range subs {
+		result := models.ConfigComponentStatus{Name: sub.name, Success: true}
+		if err := sub.fn(sub.applied, next); err != nil {
+			slog.Error("Failed to apply config", "component", sub.name, "error", err)
+			result.Success = false
+			result.Error = err.Error()
+			reloadStatus.Success = false
+			reloadStatus.Failures++
+			metrics.ConfigComponentFailed(sub.name)
+		} else {
+			sub.applied = next
+		}
+		reloadStatus.Components = append(reloadStatus.Components, result)
+	}
+	slog.Info("Config reloaded")
+}
+
+// closeAfterDrain 等待 poolDrainDelay 后关闭旧连接池
+func closeAfterDrain(name string, closer io.Closer) {
+	time.AfterFunc(poolDrainDelay, func() {
+		if err := closer.Close(); err != nil {
+			slog.Error("Failed to close old pool", "pool", name, "error", err)
+		}
+	})
+}
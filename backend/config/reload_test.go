@@ -0,0 +1,215 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+const testConfig = `
+server:
+  port: %d
+  cors:
+    allowed_origins: ["http://localhost:3000"]
+    allowed_methods: [GET, POST]
+database:
+  driver: mysql
+  host: localhost
+  port: 3306
+  username: root
+  dbname: test
+redis:
+  mode: single
+  single:
+    host: localhost
+    port: %d
+auth:
+  jwt_secret: 0123456789abcdef0123456789abcdef
+`
+
+func writeTestConfig(t *testing.T, path string, port int) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(testConfig, port, port)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// dryRunDB 不连接数据库的 gorm 实例，用于替换连接池
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// TestReloadConcurrentReads 重载替换配置快照、数据库和 Redis 客户端的同时并发读取，需使用 go test -race 运行
+func TestReloadConcurrentReads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeTestConfig(t, path, 8000)
+	SetPath(path, "")
+	if _, err := Load(); err != nil {
+		t.Fatal(err)
+	}
+	// 配置已由测试加载，跳过 GetConfig 首次调用时的加载和文件监听
+	once.Do(func() {})
+
+	OnReload("test", func(old, new *Config) error {
+		db.Store(dryRunDB(t))
+		client := redis.NewClient(&redis.Options{
+			Addr: fmt.Sprintf("localhost:%d", new.Redis.Single.Port),
+		})
+		if prev := rdb.Swap(newRedisHandle(client)); prev != nil {
+			prev.Close()
+		}
+		return nil
+	})
+	t.Cleanup(func() {
+		subMu.Lock()
+		subscribers = nil
+		subMu.Unlock()
+		if client := GetRDB(); client != nil {
+			client.Close()
+		}
+	})
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				// 同一快照中的字段来自同一次加载
+				cfg := GetConfig()
+				if cfg.Server.Port != cfg.Redis.Single.Port {
+					t.Errorf("torn snapshot: server.port=%d redis.single.port=%d", cfg.Server.Port, cfg.Redis.Single.Port)
+					return
+				}
+				// 修改副本不影响其它读取者
+				cfg.Server.Cors.AllowedOrigins[0] = "http://modified"
+
+				_ = Server().Port
+				_ = Redis().Console.DeniedCommands
+				if conn := GetDB(); conn != nil {
+					_ = conn.Dialector.Name()
+				}
+				if client := GetRDB(); client != nil {
+					_ = client.(*redis.Client).Options().Addr
+				}
+			}
+		}()
+	}
+
+	for i := 1; i <= 50; i++ {
+		writeTestConfig(t, path, 8000+i)
+		reload()
+		if status := ReloadStatus(); !status.Success {
+			t.Fatalf("reload %d failed: %s", i, status.Error)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	cfg := GetConfig()
+	if cfg.Server.Port != 8050 {
+		t.Errorf("server.port = %d, want 8050", cfg.Server.Port)
+	}
+	if got := cfg.Server.Cors.AllowedOrigins[0]; got != "http://localhost:3000" {
+		t.Errorf("snapshot was modified through a copy: allowed_origins[0] = %q", got)
+	}
+	if got := GetRDB().(*redis.Client).Options().Addr; got != "localhost:8050" {
+		t.Errorf("redis addr = %q, want localhost:8050", got)
+	}
+}
+
+// TestReloadRetriesFailedComponent 组件应用失败后，重载同一份配置时仍与它上次成功应用的配置比较并重试
+func TestReloadRetriesFailedComponent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeTestConfig(t, path, 9000)
+	SetPath(path, "")
+	if _, err := Load(); err != nil {
+		t.Fatal(err)
+	}
+	once.Do(func() {})
+
+	var seen []int
+	fail := true
+	OnReload("test", func(old, new *Config) error {
+		seen = append(seen, old.Server.Port)
+		if fail {
+			fail = false
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	})
+	t.Cleanup(func() {
+		subMu.Lock()
+		subscribers = nil
+		subMu.Unlock()
+	})
+
+	writeTestConfig(t, path, 9001)
+	reload()
+	if status := ReloadStatus(); status.Success {
+		t.Fatal("first reload should report the component failure")
+	}
+	reload()
+	if status := ReloadStatus(); !status.Success {
+		t.Fatalf("second reload failed: %s", status.Error)
+	}
+	reload()
+
+	if want := []int{9000, 9000, 9001}; fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Errorf("old ports = %v, want %v", seen, want)
+	}
+}
+
+// TestAcquireRDBDefersClose 被替换的客户端在最后一个使用者释放后才关闭
+func TestAcquireRDBDefersClose(t *testing.T) {
+	old := redis.NewClient(&redis.Options{Addr: "localhost:1"})
+	rdb.Store(newRedisHandle(old))
+	t.Cleanup(func() {
+		if client := GetRDB(); client != nil {
+			client.Close()
+		}
+		rdb.Store(nil)
+	})
+
+	client, release := AcquireRDB()
+	if client != old {
+		t.Fatal("AcquireRDB returned a different client")
+	}
+	prev := rdb.Swap(newRedisHandle(redis.NewClient(&redis.Options{Addr: "localhost:2"})))
+	prev.Close()
+
+	if err := old.Ping(context.Background()).Err(); errors.Is(err, redis.ErrClosed) {
+		t.Fatal("client closed while still acquired")
+	}
+	release()
+	release()
+	if err := old.Ping(context.Background()).Err(); !errors.Is(err, redis.ErrClosed) {
+		t.Errorf("ping after release = %v, want %v", err, redis.ErrClosed)
+	}
+	if prev.acquire() {
+		t.Error("acquired a closed client")
+	}
+}
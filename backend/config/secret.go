@@ -0,0 +1,172 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+const (
+	// encPrefix 加密值前缀，后面是 base64 编码的 nonce 和 AES-256-GCM 密文
+	encPrefix = "enc:"
+	// filePrefix 文件引用前缀，如 file:/run/secrets/db_pass，读取文件内容作为值
+	filePrefix = "file:"
+
+	secretKeySize = 32
+)
+
+// SecretKeyPath 返回加密密钥文件路径：GO_WEB_SECRET_KEY_FILE，默认为配置文件同目录下的 secret.key
+func SecretKeyPath() (string, error) {
+	if path := os.Getenv("GO_WEB_SECRET_KEY_FILE"); path != "" {
+		return path, nil
+	}
+	base, err := resolveConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(base), "secret.key"), nil
+}
+
+// GenerateSecretKey 生成随机密钥并写入 path，文件已存在时返回错误，避免覆盖后无法解密已有的值
+func GenerateSecretKey(path string) error {
+	key := make([]byte, secretKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// EncryptSecret 使用密钥文件加密明文，返回可直接写入配置文件的 enc: 值
+func EncryptSecret(keyPath, plaintext string) (string, error) {
+	aead, err := secretCipher(keyPath)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptSecret(aead cipher.AEAD, value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted value: too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("decrypt failed: wrong key or corrupted value")
+	}
+	return string(plaintext), nil
+}
+
+// secretCipher 读取 base64 编码的 32 字节密钥
+func secretCipher(keyPath string) (cipher.AEAD, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("read secret key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != secretKeySize {
+		return nil, fmt.Errorf("secret key %s must be %d base64-encoded bytes", keyPath, secretKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// secretResolver 解析 enc: 和 file: 值，密钥只在遇到加密值时读取
+type secretResolver struct {
+	aead cipher.AEAD
+}
+
+func (r *secretResolver) resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, encPrefix):
+		if r.aead == nil {
+			keyPath, err := SecretKeyPath()
+			if err != nil {
+				return "", err
+			}
+			if r.aead, err = secretCipher(keyPath); err != nil {
+				return "", err
+			}
+		}
+		return decryptSecret(r.aead, value)
+
+	case strings.HasPrefix(value, filePrefix):
+		data, err := os.ReadFile(strings.TrimPrefix(value, filePrefix))
+		if err != nil {
+			return "", err
+		}
+		// 密钥文件通常以换行结尾
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return value, nil
+}
+
+// resolveSecrets 按 yaml 标签遍历配置，解密或读取所有 enc:、file: 开头的字符串
+func resolveSecrets(v reflect.Value, path string, r *secretResolver) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := path + name
+		fv := v.Field(i)
+		switch {
+		case fv.Kind() == reflect.Struct:
+			if err := resolveSecrets(fv, key+".", r); err != nil {
+				return err
+			}
+		case fv.Kind() == reflect.String:
+			s, err := r.resolve(fv.String())
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			fv.SetString(s)
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+			for j := 0; j < fv.Len(); j++ {
+				s, err := r.resolve(fv.Index(j).String())
+				if err != nil {
+					return fmt.Errorf("%s[%d]: %w", key, j, err)
+				}
+				fv.Index(j).SetString(s)
+			}
+		}
+	}
+	return nil
+}
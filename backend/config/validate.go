@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var configValidator = newConfigValidator()
+
+func newConfigValidator() *validator.Validate {
+	v := validator.New()
+	// 错误中使用 yaml 字段名，与配置文件保持一致
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterValidation("cors_origin", validCORSOrigin)
+	v.RegisterStructValidation(validateRedisMode, RedisConfig{})
+	return v
+}
+
+// validCORSOrigin 允许 * 或 scheme://host[:port] 形式的来源，不能带路径
+func validCORSOrigin(fl validator.FieldLevel) bool {
+	origin := fl.Field().String()
+	if origin == "*" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.Path == "" && u.RawQuery == "" && u.Fragment == "" && u.User == nil
+}
+
+// validateRedisMode 检查当前模式必需的连接配置，其它模式的配置不做要求
+func validateRedisMode(sl validator.StructLevel) {
+	rc := sl.Current().Interface().(RedisConfig)
+	switch rc.Mode {
+	case "single":
+		if rc.Single.Host == "" {
+			sl.ReportError(rc.Single.Host, "single.host", "Host", "required_mode", rc.Mode)
+		}
+		if rc.Single.Port == 0 {
+			sl.ReportError(rc.Single.Port, "single.port", "Port", "required_mode", rc.Mode)
+		}
+	case "cluster":
+		if len(rc.Cluster.Addrs) == 0 {
+			sl.ReportError(rc.Cluster.Addrs, "cluster.addrs", "Addrs", "required_mode", rc.Mode)
+		}
+	case "sentinel":
+		if rc.Sentinel.MasterName == "" {
+			sl.ReportError(rc.Sentinel.MasterName, "sentinel.master_name", "MasterName", "required_mode", rc.Mode)
+		}
+		if len(rc.Sentinel.Addrs) == 0 {
+			sl.ReportError(rc.Sentinel.Addrs, "sentinel.addrs", "Addrs", "required_mode", rc.Mode)
+		}
+	}
+}
+
+// Validate 检查配置是否完整有效，返回的错误逐行列出所有问题字段
+func (c *Config) Validate() error {
+	err := configValidator.Struct(c)
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	lines := make([]string, len(errs))
+	for i, fe := range errs {
+		lines[i] = fmt.Sprintf("  %s: %s", configFieldPath(fe), configFieldMessage(fe))
+	}
+	return fmt.Errorf("invalid config:\n%s", strings.Join(lines, "\n"))
+}
+
+// configFieldPath 去掉命名空间开头的结构体名，如 Config.redis.mode -> redis.mode
+func configFieldPath(fe validator.FieldError) string {
+	_, path, _ := strings.Cut(fe.Namespace(), ".")
+	return path
+}
+
+func configFieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_with":
+		return "is required when " + snakeCase(fe.Param()) + " is set"
+	case "required_mode":
+		return fmt.Sprintf("is required when redis.mode is %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s, got %q", strings.ReplaceAll(fe.Param(), " ", ", "), fmt.Sprint(fe.Value()))
+	case "min", "max":
+		return configRangeMessage(fe)
+	case "email":
+		return "must be a valid email address"
+	case "hostname_port":
+		return fmt.Sprintf("must be host:port, got %q", fe.Value())
+	case "cors_origin":
+		return fmt.Sprintf("must be * or an origin like http://localhost:5173, got %q", fe.Value())
+	case "file":
+		return fmt.Sprintf("file %q does not exist", fe.Value())
+	}
+	return "failed " + fe.Tag() + " check"
+}
+
+func configRangeMessage(fe validator.FieldError) string {
+	bound := "at least"
+	if fe.Tag() == "max" {
+		bound = "at most"
+	}
+	switch fe.Kind() {
+	case reflect.String:
+		// 可能是密码或密钥，不回显内容
+		return fmt.Sprintf("must be %s %s characters long", bound, fe.Param())
+	case reflect.Slice:
+		return fmt.Sprintf("must have %s %s items", bound, fe.Param())
+	}
+	return fmt.Sprintf("must be %s %s, got %v", bound, fe.Param(), fe.Value())
+}
+
+// snakeCase 将 required_with 参数中的 Go 字段名转换为 yaml 字段名，如 KeyFile -> key_file
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
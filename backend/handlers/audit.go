@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetAuditLogs 查询审计记录，按时间倒序
+// 支持 actor、action（可用 table.* 前缀）、target、status、from/to 过滤和 page/pageSize 分页
+func GetAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 20
+	}
+
+	query, err := filterAuditLogs(c, config.GetDB().WithContext(c).Model(&models.AuditLog{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := models.AuditLogPage{Total: total, Page: page, PageSize: pageSize}
+	result.TotalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	err = query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&result.Items).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportAuditLogs 按相同过滤条件导出为 JSON Lines，逐行写出
+func ExportAuditLogs(c *gin.Context) {
+	query, err := filterAuditLogs(c, config.GetDB().WithContext(c).Model(&models.AuditLog{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := query.Order("id").Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("audit_%s.jsonl", time.Now().Format("20060102_150405"))
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	enc := json.NewEncoder(c.Writer)
+	for rows.Next() {
+		var entry models.AuditLog
+		if err := config.GetDB().WithContext(c).ScanRows(rows, &entry); err != nil {
+			// 响应头已发送，只能中断输出
+			c.Error(err)
+			return
+		}
+		if err := enc.Encode(entry); err != nil {
+			return
+		}
+	}
+}
+
+func filterAuditLogs(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	if actor := c.Query("actor"); actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+	if action := c.Query("action"); action != "" {
+		if prefix, ok := strings.CutSuffix(action, "*"); ok {
+			query = query.Where("action LIKE ?", prefix+"%")
+		} else {
+			query = query.Where("action = ?", action)
+		}
+	}
+	if target := c.Query("target"); target != "" {
+		query = query.Where("target LIKE ?", "%"+target+"%")
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if v := c.Query("from"); v != "" {
+		t, _, err := parseUserTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("from 格式错误: %v", err)
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+	if v := c.Query("to"); v != "" {
+		t, dateOnly, err := parseUserTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("to 格式错误: %v", err)
+		}
+		if dateOnly {
+			query = query.Where("created_at < ?", t.AddDate(0, 0, 1))
+		} else {
+			query = query.Where("created_at <= ?", t)
+		}
+	}
+	return query, nil
+}
+
+// formatRedisArgs 按 redis-cli 的格式拼接命令参数，含空白或特殊字符的参数加引号
+func formatRedisArgs(args []interface{}) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		s := fmt.Sprint(arg)
+		if b, ok := arg.([]byte); ok {
+			s = string(b)
+		}
+		if s == "" || !utf8.ValidString(s) || strings.IndexFunc(s, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '"' || r == '\'' || !unicode.IsPrint(r)
+		}) >= 0 {
+			s = strconv.Quote(s)
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, " ")
+}
+
+// auditValue 审计记录中代替写入的值，只保留长度和 SHA-256 前缀，可以比对是否写入了相同的值而不保存内容
+func auditValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("<%d bytes sha256:%x>", len(value), sum[:8])
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// dummyPasswordHash 用户不存在时仍执行一次 bcrypt 比较，避免通过响应时间判断邮箱是否存在
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+var errRefreshTokenInvalid = errors.New("invalid refresh token")
+
+// Login 邮箱密码登录，返回访问令牌和刷新令牌
+func Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		userError(c, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	var user models.User
+	err := config.GetDB().WithContext(c).Where("email = ?", strings.ToLower(strings.TrimSpace(req.Email))).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	hash := []byte(user.PasswordHash)
+	if err != nil || len(hash) == 0 {
+		hash = dummyPasswordHash
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) != nil || user.PasswordHash == "" {
+		userError(c, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password")
+		return
+	}
+
+	var pair models.TokenPair
+	err = config.GetDB().WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		pair, err = issueTokens(tx, &user, newTokenFamily())
+		return err
+	})
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// RefreshToken 用刷新令牌换取新的令牌，旧刷新令牌随即失效
+func RefreshToken(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		userError(c, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	var pair models.TokenPair
+	err := config.GetDB().WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		err := tx.Where("token_hash = ?", hashRefreshToken(req.RefreshToken)).First(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errRefreshTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+		if time.Now().After(token.ExpiresAt) {
+			return errRefreshTokenInvalid
+		}
+
+		// 条件更新保证同一令牌并发刷新时只有一个请求成功
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", token.ID).
+			Update("revoked_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errRefreshTokenReused{familyID: token.FamilyID}
+		}
+
+		var user models.User
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errRefreshTokenInvalid
+			}
+			return err
+		}
+
+		pair, err = issueTokens(tx, &user, token.FamilyID)
+		return err
+	})
+
+	var reused errRefreshTokenReused
+	if errors.As(err, &reused) {
+		// 已吊销的令牌再次出现，可能已泄露，吊销整个 family
+		if err := revokeTokenFamily(config.GetDB().WithContext(c), reused.familyID); err != nil {
+			userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+		err = errRefreshTokenInvalid
+	}
+	if errors.Is(err, errRefreshTokenInvalid) {
+		userError(c, http.StatusUnauthorized, "INVALID_REFRESH_TOKEN", "Invalid or expired refresh token")
+		return
+	}
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// Logout 吊销刷新令牌所在的 family，访问令牌在过期前仍然有效
+func Logout(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		userError(c, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	var token models.RefreshToken
+	err := config.GetDB().WithContext(c).Where("token_hash = ?", hashRefreshToken(req.RefreshToken)).First(&token).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if err == nil {
+		if err := revokeTokenFamily(config.GetDB().WithContext(c), token.FamilyID); err != nil {
+			userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// GetCurrentUser 获取当前登录用户
+func GetCurrentUser(c *gin.Context) {
+	claims, _ := middleware.CurrentUser(c)
+	var user models.User
+
+	if err := config.GetDB().WithContext(c).First(&user, claims.UserID()).Error; err != nil {
+		userError(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+type errRefreshTokenReused struct {
+	familyID string
+}
+
+func (errRefreshTokenReused) Error() string {
+	return "refresh token reused"
+}
+
+// issueTokens 签发访问令牌，并在 familyID 下保存新的刷新令牌
+func issueTokens(tx *gorm.DB, user *models.User, familyID string) (models.TokenPair, error) {
+	access, err := middleware.NewAccessToken(user)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	refresh := randomToken(32)
+	err = tx.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(refresh),
+		ExpiresAt: time.Now().Add(middleware.RefreshTokenTTL()),
+	}).Error
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return models.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(middleware.AccessTokenTTL().Seconds()),
+		User:         *user,
+	}, nil
+}
+
+func revokeTokenFamily(db *gorm.DB, familyID string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// revokeUserTokens 吊销用户的所有刷新令牌，用于修改密码和删除用户
+func revokeUserTokens(db *gorm.DB, userID uint) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newTokenFamily() string {
+	return randomToken(16)
+}
+
+func randomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wgcoder2024/go-web/backend/config"
+)
+
+// GetConfigStatus 获取配置文件及最近一次热重载的结果
+func GetConfigStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, config.ReloadStatus())
+}
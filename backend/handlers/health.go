@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+// readyCheckTimeout 每个依赖检查的超时时间，应小于探针的 timeoutSeconds
+const readyCheckTimeout = 2 * time.Second
+
+// Healthz 存活检查，只要进程能处理请求就返回 200，不检查依赖
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, models.HealthStatus{Status: "ok", Time: time.Now()})
+}
+
+// Readyz 就绪检查，并行 ping MySQL 和 Redis，任一失败时返回 503
+// 接口无需认证，只返回 ok/fail，失败原因记录在服务端日志中
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyCheckTimeout)
+	defer cancel()
+
+	checks := map[string]func(context.Context) models.DependencyStatus{
+		"mysql": checkMySQL,
+		"redis": checkRedis,
+	}
+	result := models.HealthStatus{
+		Status: "ok",
+		Time:   time.Now(),
+		Checks: make(map[string]models.DependencyStatus, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) models.DependencyStatus) {
+			defer wg.Done()
+			status := check(ctx)
+			mu.Lock()
+			result.Checks[name] = status
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	for name, status := range result.Checks {
+		if status.Status != "ok" {
+			result.Status = "fail"
+			slog.WarnContext(c, "Readiness check failed", "dependency", name, "error", status.Error)
+		}
+	}
+
+	// 重载失败时仍使用之前的配置，不影响就绪状态；接口无需认证，不返回文件路径和错误详情
+	result.Config = configHealth(config.ReloadStatus())
+
+	code := http.StatusOK
+	if result.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, result)
+}
+
+func configHealth(reload models.ConfigReloadStatus) *models.ConfigHealth {
+	health := &models.ConfigHealth{
+		Status:     okOrFail(reload.Success),
+		LoadedAt:   reload.LoadedAt,
+		Components: make(map[string]string, len(reload.Components)),
+	}
+	for _, component := range reload.Components {
+		health.Components[component.Name] = okOrFail(component.Success)
+	}
+	return health
+}
+
+func okOrFail(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "fail"
+}
+
+func checkMySQL(ctx context.Context) models.DependencyStatus {
+	db := config.GetDB()
+	if db == nil {
+		return dependencyStatus(0, errors.New("not initialized"), nil)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return dependencyStatus(0, err, nil)
+	}
+
+	start := time.Now()
+	err = sqlDB.PingContext(ctx)
+	latency := time.Since(start)
+
+	s := sqlDB.Stats()
+	return dependencyStatus(latency, err, models.DBPoolStats{
+		MaxOpen:           s.MaxOpenConnections,
+		Open:              s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitDuration:      durationMillis(s.WaitDuration),
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	})
+}
+
+func checkRedis(ctx context.Context) models.DependencyStatus {
+	rdb := config.GetRDB()
+	if rdb == nil {
+		return dependencyStatus(0, errors.New("not initialized"), nil)
+	}
+
+	start := time.Now()
+	err := rdb.Ping(ctx).Err()
+	latency := time.Since(start)
+
+	s := rdb.PoolStats()
+	return dependencyStatus(latency, err, models.RedisPoolStats{
+		Hits:       s.Hits,
+		Misses:     s.Misses,
+		Timeouts:   s.Timeouts,
+		TotalConns: s.TotalConns,
+		IdleConns:  s.IdleConns,
+		StaleConns: s.StaleConns,
+	})
+}
+
+func dependencyStatus(latency time.Duration, err error, pool interface{}) models.DependencyStatus {
+	status := models.DependencyStatus{Status: "ok", Latency: durationMillis(latency), Pool: pool}
+	if err != nil {
+		status.Status = "fail"
+		status.Error = err.Error()
+	}
+	return status
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
 )
 
 type RedisEntry struct {
@@ -21,7 +22,7 @@ type RedisEntry struct {
 // GetRedisKeys 获取所有键
 func GetRedisKeys(c *gin.Context) {
 	pattern := c.DefaultQuery("pattern", "*")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	var entries []RedisEntry
 
@@ -29,7 +30,7 @@ func GetRedisKeys(c *gin.Context) {
 	var keys []string
 	var err error
 
-	if cluster, ok := config.RDB.(*redis.ClusterClient); ok {
+	if cluster, ok := config.GetRDB().(*redis.ClusterClient); ok {
 		// 集群模式：遍历所有节点获取键
 		err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
 			nodeKeys, err := client.Keys(ctx, pattern).Result()
@@ -40,7 +41,7 @@ func GetRedisKeys(c *gin.Context) {
 			for _, key := range nodeKeys {
 				keyType, _ := client.Type(ctx, key).Result()
 				value, _ := client.Get(ctx, key).Result()
-				ttl := config.RDB.TTL(ctx, key).Val().Seconds()
+				ttl := config.GetRDB().TTL(ctx, key).Val().Seconds()
 				node := client.Options().Addr
 
 				entries = append(entries, RedisEntry{
@@ -55,16 +56,16 @@ func GetRedisKeys(c *gin.Context) {
 		})
 	} else {
 		// 单机模式
-		keys, err = config.RDB.Keys(ctx, pattern).Result()
+		keys, err = config.GetRDB().Keys(ctx, pattern).Result()
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
 		for _, key := range keys {
-			keyType, _ := config.RDB.Type(ctx, key).Result()
-			value, _ := config.RDB.Get(ctx, key).Result()
-			ttl := config.RDB.TTL(ctx, key).Val().Seconds()
+			keyType, _ := config.GetRDB().Type(ctx, key).Result()
+			value, _ := config.GetRDB().Get(ctx, key).Result()
+			ttl := config.GetRDB().TTL(ctx, key).Val().Seconds()
 
 			entries = append(entries, RedisEntry{
 				Key:   key,
@@ -91,15 +92,19 @@ func SetRedisKey(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
-	var err error
+	ctx := c.Request.Context()
+	var cmd *redis.StatusCmd
 	if entry.TTL > 0 {
-		err = config.RDB.Set(ctx, entry.Key, entry.Value, time.Duration(entry.TTL)*time.Second).Err()
+		cmd = config.GetRDB().Set(ctx, entry.Key, entry.Value, time.Duration(entry.TTL)*time.Second)
 	} else {
-		err = config.RDB.Set(ctx, entry.Key, entry.Value, 0).Err()
+		cmd = config.GetRDB().Set(ctx, entry.Key, entry.Value, 0)
 	}
 
-	if err != nil {
+	// 审计记录不保存值本身
+	args := append([]interface{}(nil), cmd.Args()...)
+	args[2] = auditValue(entry.Value)
+	middleware.AuditCommand(c, formatRedisArgs(args))
+	if err := cmd.Err(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -110,10 +115,11 @@ func SetRedisKey(c *gin.Context) {
 // DeleteRedisKey 删除键
 func DeleteRedisKey(c *gin.Context) {
 	key := c.Param("key")
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
-	err := config.RDB.Del(ctx, key).Err()
-	if err != nil {
+	cmd := config.GetRDB().Del(ctx, key)
+	middleware.AuditCommand(c, formatRedisArgs(cmd.Args()))
+	if err := cmd.Err(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
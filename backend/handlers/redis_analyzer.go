@@ -0,0 +1,622 @@
+package handlers
+
+import (
+	"container/heap"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+const (
+	// maxRedisAnalyzeJobs 内存中最多保留的分析任务数，超出时丢弃最早完成的任务
+	maxRedisAnalyzeJobs = 20
+	// maxRunningRedisAnalyzeJobs 同时运行的分析任务上限，每个任务都会对全部主节点执行 SCAN 和 MEMORY USAGE
+	maxRunningRedisAnalyzeJobs = 4
+	// maxRunningRedisAnalyzeJobsPerUser 每个用户同时运行的分析任务上限
+	maxRunningRedisAnalyzeJobsPerUser = 2
+	// maxRedisPrefixResults 返回的前缀统计条数上限，其余合并到 (other)
+	maxRedisPrefixResults = 200
+)
+
+var errRedisAnalyzeLimit = errors.New("已达到 maxKeys 上限")
+
+// ttlBuckets TTL 分布区间，按上限从小到大排列
+var ttlBuckets = []struct {
+	name  string
+	limit time.Duration
+}{
+	{"lt_1m", time.Minute},
+	{"1m_1h", time.Hour},
+	{"1h_1d", 24 * time.Hour},
+	{"1d_7d", 7 * 24 * time.Hour},
+	{"gt_7d", 0},
+}
+
+type redisAnalyzeJob struct {
+	mu       sync.Mutex
+	job      models.RedisAnalyzeJob
+	analyzer *redisKeyAnalyzer
+	cancel   context.CancelFunc
+	// owner 启动任务的用户，用于限制每个用户同时运行的任务数
+	owner string
+}
+
+var (
+	redisAnalyzeMu    sync.Mutex
+	redisAnalyzeJobs  = make(map[string]*redisAnalyzeJob)
+	redisAnalyzeOrder []string
+
+	// redisAnalyzeWG 运行中的任务，退出时等待任务结束
+	redisAnalyzeWG sync.WaitGroup
+)
+
+// StartRedisAnalyze 启动后台大键/热键分析任务
+func StartRedisAnalyze(c *gin.Context) {
+	var req models.RedisAnalyzeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Pattern == "" {
+		req.Pattern = "*"
+	}
+	if req.Delimiter == "" {
+		req.Delimiter = ":"
+	}
+	if req.Depth <= 0 {
+		req.Depth = 1
+	}
+	if req.TopN <= 0 {
+		req.TopN = 20
+	}
+	if req.ScanCount <= 0 {
+		req.ScanCount = 1000
+	}
+	if req.Samples <= 0 {
+		req.Samples = 5
+	}
+
+	// 任务在请求结束后继续运行，只沿用请求 ID 等值
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.Request.Context()))
+	j := &redisAnalyzeJob{
+		job: models.RedisAnalyzeJob{
+			ID:        newRedisJobID(),
+			Status:    "running",
+			Request:   req,
+			StartedAt: time.Now(),
+		},
+		analyzer: newRedisKeyAnalyzer(req),
+		cancel:   cancel,
+		owner:    requestActor(c),
+	}
+
+	redisAnalyzeMu.Lock()
+	running, mine := 0, 0
+	for _, other := range redisAnalyzeJobs {
+		if other.snapshot(false).Status == "running" {
+			running++
+			if other.owner == j.owner {
+				mine++
+			}
+		}
+	}
+	if running >= maxRunningRedisAnalyzeJobs || mine >= maxRunningRedisAnalyzeJobsPerUser {
+		redisAnalyzeMu.Unlock()
+		cancel()
+		msg := fmt.Sprintf("同时运行的分析任务已达上限（%d 个），请等待任务结束或取消任务后重试", maxRunningRedisAnalyzeJobs)
+		if mine >= maxRunningRedisAnalyzeJobsPerUser {
+			msg = fmt.Sprintf("每个用户最多同时运行 %d 个分析任务，请等待任务结束或取消任务后重试", maxRunningRedisAnalyzeJobsPerUser)
+		}
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": msg})
+		return
+	}
+	redisAnalyzeJobs[j.job.ID] = j
+	redisAnalyzeOrder = append(redisAnalyzeOrder, j.job.ID)
+	pruneRedisAnalyzeJobs()
+	redisAnalyzeWG.Add(1)
+	redisAnalyzeMu.Unlock()
+
+	go func() {
+		defer redisAnalyzeWG.Done()
+		j.run(ctx)
+	}()
+
+	c.JSON(http.StatusAccepted, j.snapshot(false))
+}
+
+// GetRedisAnalyzeJobs 获取分析任务列表（不含结果），只返回键模式在授权范围内的任务
+func GetRedisAnalyzeJobs(c *gin.Context) {
+	redisAnalyzeMu.Lock()
+	all := make([]models.RedisAnalyzeJob, 0, len(redisAnalyzeOrder))
+	for i := len(redisAnalyzeOrder) - 1; i >= 0; i-- {
+		all = append(all, redisAnalyzeJobs[redisAnalyzeOrder[i]].snapshot(false))
+	}
+	redisAnalyzeMu.Unlock()
+
+	jobs := make([]models.RedisAnalyzeJob, 0, len(all))
+	for _, job := range all {
+		ok, err := middleware.Allowed(c, "redis.read", middleware.PatternResource(job.Request.Pattern))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if ok {
+			jobs = append(jobs, job)
+		}
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// RedisAnalyzeJobPattern 路径参数 id 对应任务的键模式，用于检查授权范围
+func RedisAnalyzeJobPattern(c *gin.Context) (string, error) {
+	redisAnalyzeMu.Lock()
+	defer redisAnalyzeMu.Unlock()
+
+	if j, ok := redisAnalyzeJobs[c.Param("id")]; ok {
+		return j.job.Request.Pattern, nil
+	}
+	return "", nil
+}
+
+// GetRedisAnalyzeJob 获取分析任务状态和结果
+func GetRedisAnalyzeJob(c *gin.Context) {
+	redisAnalyzeMu.Lock()
+	j, ok := redisAnalyzeJobs[c.Param("id")]
+	redisAnalyzeMu.Unlock()
+
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分析任务不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, j.snapshot(true))
+}
+
+// DeleteRedisAnalyzeJob 取消运行中的任务或删除已完成的任务
+func DeleteRedisAnalyzeJob(c *gin.Context) {
+	id := c.Param("id")
+
+	redisAnalyzeMu.Lock()
+	j, ok := redisAnalyzeJobs[id]
+	if ok && j.snapshot(false).Status != "running" {
+		delete(redisAnalyzeJobs, id)
+		for i, jobID := range redisAnalyzeOrder {
+			if jobID == id {
+				redisAnalyzeOrder = append(redisAnalyzeOrder[:i], redisAnalyzeOrder[i+1:]...)
+				break
+			}
+		}
+		redisAnalyzeMu.Unlock()
+		c.JSON(http.StatusOK, gin.H{"message": "分析任务已删除"})
+		return
+	}
+	redisAnalyzeMu.Unlock()
+
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分析任务不存在"})
+		return
+	}
+
+	j.cancel()
+	c.JSON(http.StatusOK, gin.H{"message": "分析任务已取消"})
+}
+
+// StopRedisAnalyzeJobs 取消所有运行中的分析任务并等待结束，超过 ctx 期限时返回错误
+func StopRedisAnalyzeJobs(ctx context.Context) error {
+	redisAnalyzeMu.Lock()
+	for _, j := range redisAnalyzeJobs {
+		j.cancel()
+	}
+	redisAnalyzeMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		redisAnalyzeWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("redis analyze jobs: %w", ctx.Err())
+	}
+}
+
+// pruneRedisAnalyzeJobs 超出上限时丢弃最早的已结束任务，调用方需持有 redisAnalyzeMu
+func pruneRedisAnalyzeJobs() {
+	for i := 0; len(redisAnalyzeOrder) > maxRedisAnalyzeJobs && i < len(redisAnalyzeOrder); {
+		id := redisAnalyzeOrder[i]
+		if redisAnalyzeJobs[id].snapshot(false).Status == "running" {
+			i++
+			continue
+		}
+		delete(redisAnalyzeJobs, id)
+		redisAnalyzeOrder = append(redisAnalyzeOrder[:i], redisAnalyzeOrder[i+1:]...)
+	}
+}
+
+func (j *redisAnalyzeJob) run(ctx context.Context) {
+	defer j.cancel()
+
+	// 持有客户端直到扫描结束，配置重载替换客户端时不会中断任务
+	rdb, release := config.AcquireRDB()
+	defer release()
+
+	req := j.job.Request
+	err := scanRedisKeys(ctx, rdb, req.Pattern, req.ScanCount, j.analyzer.analyzeBatch)
+	if errors.Is(err, errRedisAnalyzeLimit) {
+		j.analyzer.warn(fmt.Sprintf("已达到 maxKeys=%d 上限，结果仅覆盖部分键", req.MaxKeys))
+		err = nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	j.job.FinishedAt = &now
+	result := j.analyzer.result()
+	j.job.Result = &result
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		j.job.Status = "canceled"
+	case err != nil:
+		j.job.Status = "failed"
+		j.job.Error = err.Error()
+	default:
+		j.job.Status = "completed"
+	}
+}
+
+func (j *redisAnalyzeJob) snapshot(withResult bool) models.RedisAnalyzeJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job := j.job
+	job.Scanned = j.analyzer.scanned.Load()
+	if !withResult {
+		job.Result = nil
+	}
+	return job
+}
+
+func newRedisJobID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return time.Now().Format("20060102150405") + "-" + hex.EncodeToString(b)
+}
+
+// redisKeyAnalyzer 汇总扫描到的键的内存、元素数量和 TTL 分布
+type redisKeyAnalyzer struct {
+	req     models.RedisAnalyzeRequest
+	scanned atomic.Int64
+	// reserved 已分配给各批次的键数，集群模式下多个节点并发扫描，先预留再分析才不会超过 MaxKeys
+	reserved atomic.Int64
+
+	mu          sync.Mutex
+	totalMemory int64
+	biggest     *keyStatHeap
+	hot         *keyStatHeap
+	hotDisabled bool
+	prefixes    map[string]*models.RedisPrefixStat
+	types       map[string]*models.RedisTypeStat
+	ttl         map[string]*models.RedisTTLBucket
+	warnings    []string
+}
+
+func newRedisKeyAnalyzer(req models.RedisAnalyzeRequest) *redisKeyAnalyzer {
+	return &redisKeyAnalyzer{
+		req: req,
+		biggest: &keyStatHeap{less: func(a, b models.RedisKeyStat) bool {
+			return a.Memory < b.Memory
+		}},
+		hot: &keyStatHeap{less: func(a, b models.RedisKeyStat) bool {
+			return a.Freq < b.Freq
+		}},
+		prefixes: make(map[string]*models.RedisPrefixStat),
+		types:    make(map[string]*models.RedisTypeStat),
+		ttl:      make(map[string]*models.RedisTTLBucket),
+	}
+}
+
+// analyzeBatch 通过管道批量获取一批键的类型、内存、TTL 和元素数量
+func (a *redisKeyAnalyzer) analyzeBatch(ctx context.Context, client *redis.Client, keys []string) error {
+	limited := false
+	if a.req.MaxKeys > 0 {
+		for {
+			reserved := a.reserved.Load()
+			n := min(int64(len(keys)), a.req.MaxKeys-reserved)
+			if n <= 0 {
+				return errRedisAnalyzeLimit
+			}
+			if a.reserved.CompareAndSwap(reserved, reserved+n) {
+				limited = n < int64(len(keys))
+				keys = keys[:n]
+				break
+			}
+		}
+	}
+
+	pipe := client.Pipeline()
+	typeCmds := make([]*redis.StatusCmd, len(keys))
+	memCmds := make([]*redis.IntCmd, len(keys))
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		typeCmds[i] = pipe.Type(ctx, key)
+		memCmds[i] = pipe.MemoryUsage(ctx, key, a.req.Samples)
+		ttlCmds[i] = pipe.PTTL(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !isRedisReplyError(err) {
+		return err
+	}
+
+	a.mu.Lock()
+	withFreq := a.req.HotKeys && !a.hotDisabled
+	a.mu.Unlock()
+
+	pipe = client.Pipeline()
+	countCmds := make([]*redis.IntCmd, len(keys))
+	freqCmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		switch typeCmds[i].Val() {
+		case "string":
+			// 字符串记录值的字节数，不计入元素数量
+			countCmds[i] = pipe.StrLen(ctx, key)
+		case "list":
+			countCmds[i] = pipe.LLen(ctx, key)
+		case "set":
+			countCmds[i] = pipe.SCard(ctx, key)
+		case "zset":
+			countCmds[i] = pipe.ZCard(ctx, key)
+		case "hash":
+			countCmds[i] = pipe.HLen(ctx, key)
+		case "stream":
+			countCmds[i] = pipe.XLen(ctx, key)
+		}
+		if withFreq {
+			freqCmds[i] = pipe.ObjectFreq(ctx, key)
+		}
+	}
+	if pipe.Len() > 0 {
+		if _, err := pipe.Exec(ctx); err != nil && !isRedisReplyError(err) {
+			return err
+		}
+	}
+
+	node := client.Options().Addr
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, key := range keys {
+		keyType := typeCmds[i].Val()
+		if keyType == "" || keyType == "none" {
+			// 扫描后已被删除
+			continue
+		}
+
+		stat := models.RedisKeyStat{
+			Key:    key,
+			Node:   node,
+			Type:   keyType,
+			Memory: memCmds[i].Val(),
+			TTL:    pttlMillis(ttlCmds[i].Val()),
+		}
+		if countCmds[i] != nil {
+			if keyType == "string" {
+				stat.Bytes = countCmds[i].Val()
+			} else {
+				stat.Elements = countCmds[i].Val()
+			}
+		}
+		if freqCmds[i] != nil {
+			if err := freqCmds[i].Err(); err != nil {
+				if !a.hotDisabled {
+					a.hotDisabled = true
+					a.warnings = append(a.warnings, "无法获取访问频率（需要 LFU 淘汰策略）："+err.Error())
+				}
+			} else {
+				stat.Freq = freqCmds[i].Val()
+				a.hot.offer(stat, a.req.TopN)
+			}
+		}
+
+		a.totalMemory += stat.Memory
+		a.biggest.offer(stat, a.req.TopN)
+		a.addPrefix(stat)
+		a.addType(stat)
+		a.addTTL(stat, ttlCmds[i].Val())
+	}
+	a.scanned.Add(int64(len(keys)))
+	if limited {
+		return errRedisAnalyzeLimit
+	}
+	return nil
+}
+
+func (a *redisKeyAnalyzer) addPrefix(stat models.RedisKeyStat) {
+	prefix := keyNamespace(stat.Key, a.req.Delimiter, a.req.Depth)
+	p, ok := a.prefixes[prefix]
+	if !ok {
+		p = &models.RedisPrefixStat{Prefix: prefix}
+		a.prefixes[prefix] = p
+	}
+	p.Keys++
+	p.Memory += stat.Memory
+	p.Elements += stat.Elements
+	p.Bytes += stat.Bytes
+}
+
+func (a *redisKeyAnalyzer) addType(stat models.RedisKeyStat) {
+	t, ok := a.types[stat.Type]
+	if !ok {
+		t = &models.RedisTypeStat{Type: stat.Type}
+		a.types[stat.Type] = t
+	}
+	t.Keys++
+	t.Memory += stat.Memory
+	t.Elements += stat.Elements
+	t.Bytes += stat.Bytes
+}
+
+func (a *redisKeyAnalyzer) addTTL(stat models.RedisKeyStat, ttl time.Duration) {
+	bucket := "no_expire"
+	if ttl >= 0 {
+		for _, b := range ttlBuckets {
+			if b.limit == 0 || ttl < b.limit {
+				bucket = b.name
+				break
+			}
+		}
+	}
+
+	t, ok := a.ttl[bucket]
+	if !ok {
+		t = &models.RedisTTLBucket{Bucket: bucket}
+		a.ttl[bucket] = t
+	}
+	t.Keys++
+	t.Memory += stat.Memory
+}
+
+func (a *redisKeyAnalyzer) warn(msg string) {
+	a.mu.Lock()
+	a.warnings = append(a.warnings, msg)
+	a.mu.Unlock()
+}
+
+// result 生成分析结果，列表均按内存从大到小排列
+func (a *redisKeyAnalyzer) result() models.RedisAnalysis {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := models.RedisAnalysis{
+		TotalKeys:   a.scanned.Load(),
+		TotalMemory: a.totalMemory,
+		BiggestKeys: a.biggest.sorted(),
+		Warnings:    a.warnings,
+	}
+	if a.req.HotKeys && !a.hotDisabled {
+		result.HotKeys = a.hot.sorted()
+	}
+
+	prefixes := make([]models.RedisPrefixStat, 0, len(a.prefixes))
+	for _, p := range a.prefixes {
+		prefixes = append(prefixes, *p)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return prefixes[i].Memory > prefixes[j].Memory })
+	if len(prefixes) > maxRedisPrefixResults {
+		other := models.RedisPrefixStat{Prefix: "(other)"}
+		for _, p := range prefixes[maxRedisPrefixResults:] {
+			other.Keys += p.Keys
+			other.Memory += p.Memory
+			other.Elements += p.Elements
+			other.Bytes += p.Bytes
+		}
+		prefixes = append(prefixes[:maxRedisPrefixResults], other)
+	}
+	result.Prefixes = prefixes
+
+	for _, t := range a.types {
+		result.Types = append(result.Types, *t)
+	}
+	sort.Slice(result.Types, func(i, j int) bool { return result.Types[i].Memory > result.Types[j].Memory })
+
+	for _, name := range append([]string{"no_expire"}, ttlBucketNames()...) {
+		if t, ok := a.ttl[name]; ok {
+			result.TTL = append(result.TTL, *t)
+		} else {
+			result.TTL = append(result.TTL, models.RedisTTLBucket{Bucket: name})
+		}
+	}
+	return result
+}
+
+func ttlBucketNames() []string {
+	names := make([]string, len(ttlBuckets))
+	for i, b := range ttlBuckets {
+		names[i] = b.name
+	}
+	return names
+}
+
+// keyNamespace 取键按分隔符切分后的前 depth 段作为命名空间，不包含键的最后一段
+func keyNamespace(key, delimiter string, depth int) string {
+	parts := strings.Split(key, delimiter)
+	n := depth
+	if n > len(parts)-1 {
+		n = len(parts) - 1
+	}
+	if n <= 0 {
+		return "(none)"
+	}
+	return strings.Join(parts[:n], delimiter)
+}
+
+// pttlMillis 将 PTTL 结果转换为毫秒，-1/-2 原样保留
+func pttlMillis(ttl time.Duration) int64 {
+	if ttl < 0 {
+		return int64(ttl)
+	}
+	return ttl.Milliseconds()
+}
+
+// isRedisReplyError 判断是否为服务端返回的错误回复（包括 nil 回复）
+func isRedisReplyError(err error) bool {
+	if err == redis.Nil {
+		return true
+	}
+	var redisErr redis.Error
+	return errors.As(err, &redisErr)
+}
+
+// keyStatHeap 保留前 N 个最大值的小顶堆
+type keyStatHeap struct {
+	items []models.RedisKeyStat
+	less  func(a, b models.RedisKeyStat) bool
+}
+
+func (h *keyStatHeap) Len() int           { return len(h.items) }
+func (h *keyStatHeap) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *keyStatHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *keyStatHeap) Push(x interface{}) { h.items = append(h.items, x.(models.RedisKeyStat)) }
+func (h *keyStatHeap) Pop() interface{} {
+	n := len(h.items)
+	item := h.items[n-1]
+	h.items = h.items[:n-1]
+	return item
+}
+
+func (h *keyStatHeap) offer(stat models.RedisKeyStat, n int) {
+	if h.Len() < n {
+		heap.Push(h, stat)
+		return
+	}
+	if h.less(h.items[0], stat) {
+		h.items[0] = stat
+		heap.Fix(h, 0)
+	}
+}
+
+func (h *keyStatHeap) sorted() []models.RedisKeyStat {
+	items := make([]models.RedisKeyStat, len(h.items))
+	copy(items, h.items)
+	sort.Slice(items, func(i, j int) bool { return h.less(items[j], items[i]) })
+	return items
+}
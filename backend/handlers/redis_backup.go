@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+const (
+	// maxRedisImportLine 导入文件单行的最大长度
+	maxRedisImportLine = 512 << 20
+	// maxRedisImportErrors 导入结果中最多返回的错误条数
+	maxRedisImportErrors = 100
+	// maxRedisImportRetries 写入期间目标键被其它客户端修改时的最大重试次数
+	maxRedisImportRetries = 3
+)
+
+// errRedisKeyExists 导入的目标键已存在
+var errRedisKeyExists = errors.New("目标键已存在")
+
+// ExportRedisKeys 导出匹配的键为 JSON Lines 文件
+// format=json 按类型导出完整值（不包含 Stream 的消费者组），format=dump 导出 DUMP 序列化结果
+func ExportRedisKeys(c *gin.Context) {
+	pattern := c.DefaultQuery("pattern", "*")
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "dump" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 只能是 json 或 dump"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	filename := fmt.Sprintf("redis-%s-%s.jsonl", format, time.Now().Format("20060102150405"))
+
+	// 导出大量键时持有客户端直到结束，配置重载替换客户端时不会中断下载
+	rdb, release := config.AcquireRDB()
+	defer release()
+
+	var (
+		mu      sync.Mutex
+		written bool
+	)
+	err := scanRedisKeys(ctx, rdb, pattern, 500, func(ctx context.Context, client *redis.Client, keys []string) error {
+		lines := make([][]byte, 0, len(keys))
+		for _, key := range keys {
+			backup, err := exportRedisKey(ctx, client, key, format)
+			if err == redis.Nil {
+				// 扫描后已被删除
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("导出键 %s 失败: %w", key, err)
+			}
+
+			line, err := json.Marshal(backup)
+			if err != nil {
+				return fmt.Errorf("导出键 %s 失败: %w", key, err)
+			}
+			lines = append(lines, line)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if !written {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Header("Content-Disposition", "attachment; filename="+filename)
+			c.Status(http.StatusOK)
+			written = true
+		}
+		for _, line := range lines {
+			c.Writer.Write(line)
+			c.Writer.Write([]byte("\n"))
+		}
+		c.Writer.Flush()
+		return nil
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err != nil {
+		if !written {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// 响应头已发送，只能在文件末尾追加错误行
+		line, _ := json.Marshal(gin.H{"error": err.Error()})
+		c.Writer.Write(line)
+		c.Writer.Write([]byte("\n"))
+		return
+	}
+	if !written {
+		// 没有匹配的键时返回空文件
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "application/x-ndjson", nil)
+	}
+}
+
+// exportRedisKey 读取单个键的类型、TTL 和值
+// 键名或值中含有非 UTF-8 字节时，键名和值中的所有字符串按 base64 编码并设置 Encoding，避免 JSON 编码时损坏
+func exportRedisKey(ctx context.Context, client *redis.Client, key, format string) (*models.RedisKeyBackup, error) {
+	keyType, err := client.Type(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if keyType == "none" {
+		return nil, redis.Nil
+	}
+
+	ttl, err := client.PTTL(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &models.RedisKeyBackup{Key: key, Type: keyType}
+	if ttl > 0 {
+		backup.TTL = ttl.Milliseconds()
+	}
+
+	if format == "dump" {
+		payload, err := client.Dump(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		backup.Dump = base64.StdEncoding.EncodeToString([]byte(payload))
+		if !utf8.ValidString(key) {
+			backup.Key = base64.StdEncoding.EncodeToString([]byte(key))
+			backup.Encoding = "base64"
+		}
+		return backup, nil
+	}
+
+	var value interface{}
+	switch keyType {
+	case "string":
+		value, err = client.Get(ctx, key).Result()
+	case "list":
+		value, err = client.LRange(ctx, key, 0, -1).Result()
+	case "set":
+		value, err = client.SMembers(ctx, key).Result()
+	case "hash":
+		value, err = client.HGetAll(ctx, key).Result()
+	case "zset":
+		var members []redis.Z
+		members, err = client.ZRangeWithScores(ctx, key, 0, -1).Result()
+		zset := make([]models.RedisZMember, len(members))
+		for i, m := range members {
+			zset[i] = models.RedisZMember{Member: fmt.Sprint(m.Member), Score: m.Score}
+		}
+		value = zset
+	case "stream":
+		var messages []redis.XMessage
+		messages, err = client.XRange(ctx, key, "-", "+").Result()
+		entries := make([]models.RedisStreamEntry, len(messages))
+		for i, m := range messages {
+			entries[i] = models.RedisStreamEntry{ID: m.ID, Values: m.Values}
+		}
+		value = entries
+	default:
+		return nil, fmt.Errorf("不支持导出 %s 类型，请使用 dump 格式", keyType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	binary := !utf8.ValidString(key)
+	mapRedisValueStrings(value, func(s string) (string, error) {
+		binary = binary || !utf8.ValidString(s)
+		return s, nil
+	})
+	if binary {
+		encode := func(s string) (string, error) {
+			return base64.StdEncoding.EncodeToString([]byte(s)), nil
+		}
+		backup.Key, _ = encode(key)
+		value, _ = mapRedisValueStrings(value, encode)
+		backup.Encoding = "base64"
+	}
+
+	backup.Value, err = json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// ImportRedisKeys 从导出文件导入键
+// conflict 指定目标键已存在时的处理方式：skip（默认）、overwrite、rename
+func ImportRedisKeys(c *gin.Context) {
+	conflict := c.DefaultPostForm("conflict", "skip")
+	if conflict != "skip" && conflict != "overwrite" && conflict != "rename" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "conflict 只能是 skip、overwrite 或 rename"})
+		return
+	}
+	suffix := c.DefaultPostForm("suffix", ":imported")
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	middleware.AuditCommand(c, fmt.Sprintf("IMPORT %s conflict=%s suffix=%s", fileHeader.Filename, conflict, suffix))
+	// 客户端断开时继续导入，避免只写入一部分；保留请求 ID 等值用于日志
+	ctx := context.WithoutCancel(c.Request.Context())
+	var result models.RedisImportResult
+	fail := func(line int, err error) {
+		result.Failed++
+		if len(result.Errors) < maxRedisImportErrors {
+			result.Errors = append(result.Errors, fmt.Sprintf("第 %d 行: %v", line, err))
+		}
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxRedisImportLine)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var backup models.RedisKeyBackup
+		if err := json.Unmarshal(scanner.Bytes(), &backup); err != nil {
+			fail(lineNo, err)
+			continue
+		}
+		key, value, err := decodeRedisBackup(&backup)
+		if err != nil {
+			fail(lineNo, err)
+			continue
+		}
+
+		// 存在检查和写入在同一个命令或事务中完成，不会覆盖导入期间被其它客户端创建的键
+		target := key
+		err = writeRedisBackup(ctx, target, &backup, value, conflict == "overwrite")
+		if errors.Is(err, errRedisKeyExists) {
+			switch conflict {
+			case "skip":
+				result.Skipped++
+				continue
+			case "rename":
+				target = key + suffix
+				for i := 2; ; i++ {
+					err = writeRedisBackup(ctx, target, &backup, value, false)
+					if !errors.Is(err, errRedisKeyExists) {
+						break
+					}
+					target = key + suffix + ":" + strconv.Itoa(i)
+				}
+			}
+		}
+		if err != nil {
+			fail(lineNo, fmt.Errorf("导入键 %s 失败: %w", backup.Key, err))
+			continue
+		}
+		middleware.AuditCommand(c, formatRedisArgs(redisImportAuditArgs(target, &backup, conflict == "overwrite")))
+		if target != key {
+			result.Renamed++
+		}
+		result.Imported++
+	}
+	if err := scanner.Err(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "result": result})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// redisImportAuditArgs 描述导入写入的一个键，值只记录长度和摘要
+func redisImportAuditArgs(key string, backup *models.RedisKeyBackup, replace bool) []interface{} {
+	name, value := redisImportCommands[backup.Type], string(backup.Value)
+	if backup.Dump != "" {
+		name, value = "RESTORE", backup.Dump
+	}
+	args := []interface{}{name, key, auditValue(value)}
+	if backup.TTL > 0 {
+		args = append(args, "PX", backup.TTL)
+	}
+	if replace {
+		args = append(args, "REPLACE")
+	}
+	return args
+}
+
+// redisImportCommands 各类型导入时使用的写入命令
+var redisImportCommands = map[string]string{
+	"string": "SET",
+	"list":   "RPUSH",
+	"set":    "SADD",
+	"hash":   "HSET",
+	"zset":   "ZADD",
+	"stream": "XADD",
+}
+
+// decodeRedisBackup 解析导入行的键名和值，Encoding 为 base64 时先解码
+// dump 格式返回 DUMP 序列化结果，其余按类型返回 string、[]string、map[string]string、[]RedisZMember 或 []RedisStreamEntry
+func decodeRedisBackup(backup *models.RedisKeyBackup) (string, interface{}, error) {
+	var decode func(s string) (string, error)
+	switch backup.Encoding {
+	case "":
+		decode = func(s string) (string, error) { return s, nil }
+	case "base64":
+		decode = func(s string) (string, error) {
+			b, err := base64.StdEncoding.DecodeString(s)
+			return string(b), err
+		}
+	default:
+		return "", nil, fmt.Errorf("不支持的编码 %q", backup.Encoding)
+	}
+
+	key, err := decode(backup.Key)
+	if err != nil {
+		return "", nil, fmt.Errorf("key: %w", err)
+	}
+	if key == "" {
+		return "", nil, fmt.Errorf("缺少 key")
+	}
+
+	if backup.Dump != "" {
+		payload, err := base64.StdEncoding.DecodeString(backup.Dump)
+		if err != nil {
+			return "", nil, fmt.Errorf("dump: %w", err)
+		}
+		return key, string(payload), nil
+	}
+
+	var value interface{}
+	switch backup.Type {
+	case "string":
+		var v string
+		err = json.Unmarshal(backup.Value, &v)
+		value = v
+	case "list", "set":
+		var v []string
+		err = json.Unmarshal(backup.Value, &v)
+		value = v
+	case "hash":
+		var v map[string]string
+		err = json.Unmarshal(backup.Value, &v)
+		value = v
+	case "zset":
+		var v []models.RedisZMember
+		err = json.Unmarshal(backup.Value, &v)
+		value = v
+	case "stream":
+		var v []models.RedisStreamEntry
+		err = json.Unmarshal(backup.Value, &v)
+		value = v
+	default:
+		return "", nil, fmt.Errorf("不支持导入 %s 类型", backup.Type)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	value, err = mapRedisValueStrings(value, decode)
+	if err != nil {
+		return "", nil, fmt.Errorf("value: %w", err)
+	}
+	return key, value, nil
+}
+
+// mapRedisValueStrings 对值中的每个字符串（包括哈希字段名和 Stream 字段名）调用 fn，返回替换后的新值
+func mapRedisValueStrings(value interface{}, fn func(string) (string, error)) (interface{}, error) {
+	var err error
+	conv := func(s string) string {
+		if err != nil {
+			return s
+		}
+		var out string
+		out, err = fn(s)
+		return out
+	}
+
+	switch v := value.(type) {
+	case string:
+		value = conv(v)
+	case []string:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = conv(s)
+		}
+		value = out
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for field, s := range v {
+			out[conv(field)] = conv(s)
+		}
+		value = out
+	case []models.RedisZMember:
+		out := make([]models.RedisZMember, len(v))
+		for i, m := range v {
+			out[i] = models.RedisZMember{Member: conv(m.Member), Score: m.Score}
+		}
+		value = out
+	case []models.RedisStreamEntry:
+		out := make([]models.RedisStreamEntry, len(v))
+		for i, e := range v {
+			values := make(map[string]interface{}, len(e.Values))
+			for field, val := range e.Values {
+				if s, ok := val.(string); ok {
+					val = conv(s)
+				}
+				values[conv(field)] = val
+			}
+			out[i] = models.RedisStreamEntry{ID: e.ID, Values: values}
+		}
+		value = out
+	}
+	return value, err
+}
+
+// writeRedisBackup 写入单个键，replace 为 false 且键已存在时返回 errRedisKeyExists
+// dump 格式使用不带 REPLACE 的 RESTORE，由 Redis 返回 BUSYKEY；其余在 WATCH 事务中检查后按类型重建
+func writeRedisBackup(ctx context.Context, key string, backup *models.RedisKeyBackup, value interface{}, replace bool) error {
+	ttl := time.Duration(backup.TTL) * time.Millisecond
+	if ttl < 0 {
+		ttl = 0
+	}
+	rdb := config.GetRDB()
+
+	if backup.Dump != "" {
+		payload := value.(string)
+		if replace {
+			return rdb.RestoreReplace(ctx, key, ttl, payload).Err()
+		}
+		err := rdb.Restore(ctx, key, ttl, payload).Err()
+		if err != nil && strings.HasPrefix(err.Error(), "BUSYKEY") {
+			return errRedisKeyExists
+		}
+		return err
+	}
+
+	write := func(pipe redis.Pipeliner) error {
+		if replace {
+			pipe.Del(ctx, key)
+		}
+		if err := writeRedisValue(ctx, pipe, key, backup.Type, value); err != nil {
+			return err
+		}
+		if ttl > 0 {
+			pipe.PExpire(ctx, key, ttl)
+		}
+		return nil
+	}
+
+	if replace {
+		_, err := rdb.TxPipelined(ctx, write)
+		return err
+	}
+
+	// WATCH 之后键被其它客户端创建或修改时 EXEC 失败，重新检查
+	for i := 0; i < maxRedisImportRetries; i++ {
+		err := rdb.Watch(ctx, func(tx *redis.Tx) error {
+			n, err := tx.Exists(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				return errRedisKeyExists
+			}
+			_, err = tx.TxPipelined(ctx, write)
+			return err
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return fmt.Errorf("键 %s 在写入期间被反复修改", key)
+}
+
+// writeRedisValue 在事务中按类型写入 decodeRedisBackup 解析出的值
+func writeRedisValue(ctx context.Context, pipe redis.Pipeliner, key, keyType string, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		pipe.Set(ctx, key, v, 0)
+	case []string:
+		if keyType == "set" {
+			pipe.SAdd(ctx, key, toInterfaces(v)...)
+		} else {
+			pipe.RPush(ctx, key, toInterfaces(v)...)
+		}
+	case map[string]string:
+		pipe.HSet(ctx, key, v)
+	case []models.RedisZMember:
+		zs := make([]redis.Z, len(v))
+		for i, m := range v {
+			zs[i] = redis.Z{Score: m.Score, Member: m.Member}
+		}
+		pipe.ZAdd(ctx, key, zs...)
+	case []models.RedisStreamEntry:
+		for _, e := range v {
+			pipe.XAdd(ctx, &redis.XAddArgs{Stream: key, ID: e.ID, Values: e.Values})
+		}
+	default:
+		return fmt.Errorf("不支持导入 %s 类型", keyType)
+	}
+	return nil
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
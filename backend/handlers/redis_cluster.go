@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+// GetRedisClusterNodes 获取集群节点、槽位分布和健康状态
+func GetRedisClusterNodes(c *gin.Context) {
+	cluster, ok := requireRedisCluster(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	nodes, err := loadRedisClusterNodes(ctx, cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 主节点键数量
+	var mu sync.Mutex
+	keys := make(map[string]int64)
+	err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		n, err := client.DBSize(ctx).Result()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		keys[client.Options().Addr] = n
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range nodes {
+		if n, ok := keys[nodes[i].Addr]; ok && nodes[i].Role == "master" {
+			nodes[i].Keys = &n
+		}
+	}
+
+	info, err := cluster.ClusterInfo(ctx).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RedisClusterTopology{
+		Nodes:   nodes,
+		Summary: summarizeRedisCluster(nodes, parseRedisInfo(info)["default"]),
+	})
+}
+
+// GetRedisClusterShards 获取 CLUSTER SHARDS，Redis 7 以下版本根据 CLUSTER NODES 构建
+func GetRedisClusterShards(c *gin.Context) {
+	cluster, ok := requireRedisCluster(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	shards, err := cluster.ClusterShards(ctx).Result()
+	if err == nil {
+		result := make([]models.RedisClusterShard, len(shards))
+		for i, shard := range shards {
+			result[i].Slots = make([]models.RedisSlotRange, len(shard.Slots))
+			for j, r := range shard.Slots {
+				result[i].Slots[j] = models.RedisSlotRange{Start: r.Start, End: r.End}
+			}
+			result[i].Nodes = make([]models.RedisShardNode, len(shard.Nodes))
+			for j, n := range shard.Nodes {
+				result[i].Nodes[j] = models.RedisShardNode{
+					ID:                n.ID,
+					Endpoint:          n.Endpoint,
+					IP:                n.IP,
+					Hostname:          n.Hostname,
+					Port:              n.Port,
+					TLSPort:           n.TLSPort,
+					Role:              n.Role,
+					ReplicationOffset: n.ReplicationOffset,
+					Health:            n.Health,
+				}
+			}
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	if !isRedisReplyError(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 旧版本不支持 CLUSTER SHARDS
+	nodes, err := loadRedisClusterNodes(ctx, cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, shardsFromRedisClusterNodes(nodes))
+}
+
+// GetRedisKeySlot 查询键所在的槽位和节点
+func GetRedisKeySlot(c *gin.Context) {
+	cluster, ok := requireRedisCluster(c)
+	if !ok {
+		return
+	}
+
+	key := c.Query("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key 不能为空"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	slot, err := cluster.ClusterKeySlot(ctx, key).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	nodes, err := loadRedisClusterNodes(ctx, cluster)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := models.RedisKeySlot{Key: key, Slot: slot, Replicas: []string{}}
+	for _, node := range nodes {
+		if node.Role != "master" {
+			continue
+		}
+		for _, r := range node.Slots {
+			if slot >= r.Start && slot <= r.End {
+				result.Master = node.Addr
+				result.MasterID = node.ID
+				result.Replicas = append(result.Replicas, node.Replicas...)
+			}
+		}
+	}
+	if result.Master == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "槽位未分配给任何节点", "slot": slot})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// requireRedisCluster 非集群模式时直接返回 400
+func requireRedisCluster(c *gin.Context) (*redis.ClusterClient, bool) {
+	cluster, ok := config.GetRDB().(*redis.ClusterClient)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "当前不是集群模式"})
+		return nil, false
+	}
+	return cluster, true
+}
+
+// loadRedisClusterNodes 执行 CLUSTER NODES 并解析，结果按地址排序
+func loadRedisClusterNodes(ctx context.Context, cluster *redis.ClusterClient) ([]models.RedisClusterNode, error) {
+	raw, err := cluster.ClusterNodes(ctx).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := parseRedisClusterNodes(raw)
+
+	// 关联主从关系
+	index := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		index[node.ID] = i
+	}
+	for _, node := range nodes {
+		if node.MasterID == "" {
+			continue
+		}
+		if i, ok := index[node.MasterID]; ok {
+			nodes[i].Replicas = append(nodes[i].Replicas, node.Addr)
+		}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Addr < nodes[j].Addr })
+	return nodes, nil
+}
+
+// parseRedisClusterNodes 解析 CLUSTER NODES 输出
+// 每行格式：<id> <ip:port@cport[,hostname]> <flags> <master> <ping-sent> <pong-recv> <config-epoch> <link-state> <slot> ...
+func parseRedisClusterNodes(raw string) []models.RedisClusterNode {
+	var nodes []models.RedisClusterNode
+	for _, line := range strings.Split(raw, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+
+		node := models.RedisClusterNode{
+			ID:        fields[0],
+			Flags:     strings.Split(fields[2], ","),
+			LinkState: fields[7],
+			Role:      "master",
+		}
+
+		addr, hostname, _ := strings.Cut(fields[1], ",")
+		node.Addr, node.BusPort, _ = strings.Cut(addr, "@")
+		node.Hostname = hostname
+
+		if fields[3] != "-" {
+			node.MasterID = fields[3]
+		}
+		node.PingSent, _ = strconv.ParseInt(fields[4], 10, 64)
+		node.PongRecv, _ = strconv.ParseInt(fields[5], 10, 64)
+		node.ConfigEpoch, _ = strconv.ParseInt(fields[6], 10, 64)
+
+		for _, flag := range node.Flags {
+			switch flag {
+			case "slave", "replica":
+				node.Role = "replica"
+			case "fail", "fail?":
+				node.Failing = true
+			}
+		}
+
+		for _, slot := range fields[8:] {
+			if strings.HasPrefix(slot, "[") {
+				// [slot->-node] 迁出中，[slot-<-node] 迁入中
+				slot = strings.Trim(slot, "[]")
+				if s, target, ok := strings.Cut(slot, "->-"); ok {
+					node.Migrating = append(node.Migrating, s+" -> "+target)
+				} else if s, source, ok := strings.Cut(slot, "-<-"); ok {
+					node.Importing = append(node.Importing, s+" <- "+source)
+				}
+				continue
+			}
+
+			startStr, endStr, isRange := strings.Cut(slot, "-")
+			start, err := strconv.ParseInt(startStr, 10, 64)
+			if err != nil {
+				continue
+			}
+			end := start
+			if isRange {
+				end, _ = strconv.ParseInt(endStr, 10, 64)
+			}
+			node.Slots = append(node.Slots, models.RedisSlotRange{Start: start, End: end})
+			node.SlotCount += end - start + 1
+		}
+
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// summarizeRedisCluster 汇总集群状态和主节点之间的槽位、键数量差异
+func summarizeRedisCluster(nodes []models.RedisClusterNode, info map[string]string) models.RedisClusterSummary {
+	summary := models.RedisClusterSummary{
+		State:                  info["cluster_state"],
+		FailingNodes:           []string{},
+		MastersWithoutReplicas: []string{},
+	}
+	summary.SlotsAssigned, _ = strconv.ParseInt(info["cluster_slots_assigned"], 10, 64)
+	summary.SlotsOK, _ = strconv.ParseInt(info["cluster_slots_ok"], 10, 64)
+	summary.SlotsPFail, _ = strconv.ParseInt(info["cluster_slots_pfail"], 10, 64)
+	summary.SlotsFail, _ = strconv.ParseInt(info["cluster_slots_fail"], 10, 64)
+	summary.CurrentEpoch, _ = strconv.ParseInt(info["cluster_current_epoch"], 10, 64)
+
+	var totalKeys int64
+	first := true
+	for _, node := range nodes {
+		if node.Failing || node.LinkState != "connected" {
+			summary.FailingNodes = append(summary.FailingNodes, node.Addr)
+		}
+		if node.Role == "replica" {
+			summary.Replicas++
+			continue
+		}
+		if node.SlotCount == 0 {
+			// 没有分配槽位的主节点不参与均衡统计
+			continue
+		}
+
+		summary.Masters++
+		if len(node.Replicas) == 0 {
+			summary.MastersWithoutReplicas = append(summary.MastersWithoutReplicas, node.Addr)
+		}
+
+		var keys int64
+		if node.Keys != nil {
+			keys = *node.Keys
+		}
+		totalKeys += keys
+
+		if first || node.SlotCount < summary.MinSlots {
+			summary.MinSlots = node.SlotCount
+		}
+		if first || node.SlotCount > summary.MaxSlots {
+			summary.MaxSlots = node.SlotCount
+		}
+		if first || keys < summary.MinKeys {
+			summary.MinKeys = keys
+		}
+		if first || keys > summary.MaxKeys {
+			summary.MaxKeys = keys
+		}
+		first = false
+	}
+
+	if summary.Masters > 0 && totalKeys > 0 {
+		avg := float64(totalKeys) / float64(summary.Masters)
+		summary.KeysImbalance = float64(summary.MaxKeys) / avg
+	}
+	return summary
+}
+
+// shardsFromRedisClusterNodes 根据 CLUSTER NODES 构建与 CLUSTER SHARDS 相同结构的结果
+func shardsFromRedisClusterNodes(nodes []models.RedisClusterNode) []models.RedisClusterShard {
+	var shards []models.RedisClusterShard
+	for _, node := range nodes {
+		if node.Role != "master" {
+			continue
+		}
+
+		shard := models.RedisClusterShard{
+			Slots: node.Slots,
+			Nodes: []models.RedisShardNode{toRedisShardNode(node)},
+		}
+		for _, replica := range nodes {
+			if replica.MasterID == node.ID {
+				shard.Nodes = append(shard.Nodes, toRedisShardNode(replica))
+			}
+		}
+		shards = append(shards, shard)
+	}
+	return shards
+}
+
+func toRedisShardNode(node models.RedisClusterNode) models.RedisShardNode {
+	host, portStr, _ := net.SplitHostPort(node.Addr)
+	port, _ := strconv.ParseInt(portStr, 10, 64)
+
+	health := "online"
+	if node.Failing || node.LinkState != "connected" {
+		health = "fail"
+	}
+
+	return models.RedisShardNode{
+		ID:       node.ID,
+		Endpoint: host,
+		IP:       host,
+		Hostname: node.Hostname,
+		Port:     port,
+		Role:     node.Role,
+		Health:   health,
+	}
+}
@@ -0,0 +1,521 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+// defaultDeniedCommands 未配置 denied_commands 时使用的禁止列表
+// 列表只匹配命令名和子命令，脚本和函数可以在服务端调用任意命令，因此整体禁止，需要时通过脚本管理接口执行
+var defaultDeniedCommands = []string{
+	"FLUSHALL",
+	"FLUSHDB",
+	"CONFIG SET",
+	"CONFIG REWRITE",
+	"CONFIG RESETSTAT",
+	"DEBUG",
+	"SHUTDOWN",
+	"MONITOR",
+	"SUBSCRIBE",
+	"PSUBSCRIBE",
+	"SSUBSCRIBE",
+	"SYNC",
+	"PSYNC",
+	"REPLICAOF",
+	"SLAVEOF",
+	"CLUSTER RESET",
+	"CLUSTER FAILOVER",
+	"EVAL",
+	"EVALSHA",
+	"EVAL_RO",
+	"EVALSHA_RO",
+	"FCALL",
+	"FCALL_RO",
+	"SCRIPT",
+	"FUNCTION",
+	"MODULE",
+	"ACL SETUSER",
+	"ACL DELUSER",
+	"ACL LOAD",
+	"ACL SAVE",
+	"CLUSTER MEET",
+	"CLUSTER FORGET",
+	"CLUSTER ADDSLOTS",
+	"CLUSTER ADDSLOTSRANGE",
+	"CLUSTER DELSLOTS",
+	"CLUSTER DELSLOTSRANGE",
+	"CLUSTER FLUSHSLOTS",
+	"CLUSTER SETSLOT",
+	"CLUSTER REPLICATE",
+	"FAILOVER",
+}
+
+// connStateCommands 改变连接状态的命令，始终禁止，不受 denied_commands 配置影响
+// 控制台命令使用连接池中的共享连接，执行后连接会被其他请求复用，
+// 切换数据库、认证用户、开启事务或关闭回复会影响之后使用该连接的所有请求
+var connStateCommands = []string{
+	"SELECT",
+	"AUTH",
+	"HELLO",
+	"RESET",
+	"QUIT",
+	"MULTI",
+	"EXEC",
+	"DISCARD",
+	"WATCH",
+	"UNWATCH",
+	"READONLY",
+	"READWRITE",
+	"CLIENT REPLY",
+	"CLIENT SETNAME",
+	"CLIENT SETINFO",
+	"CLIENT TRACKING",
+	"CLIENT CACHING",
+	"CLIENT NO-EVICT",
+	"CLIENT NO-TOUCH",
+	"UNSUBSCRIBE",
+	"PUNSUBSCRIBE",
+	"SUNSUBSCRIBE",
+}
+
+// clusterFanoutCommands 集群模式下未指定节点时需要在所有主节点上执行的无键命令
+var clusterFanoutCommands = map[string]bool{
+	"KEYS":     true,
+	"DBSIZE":   true,
+	"INFO":     true,
+	"PING":     true,
+	"SLOWLOG":  true,
+	"LASTSAVE": true,
+}
+
+// ExecuteRedisCommand 执行 Redis 控制台命令
+func ExecuteRedisCommand(c *gin.Context) {
+	var req models.RedisCommand
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	args, err := parseRedisCommandLine(req.Command)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(args) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "命令不能为空"})
+		return
+	}
+
+	middleware.AuditCommand(c, req.Command)
+	if err := checkRedisCommandAllowed(args); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	cmdArgs := make([]interface{}, len(args))
+	for i, arg := range args {
+		cmdArgs[i] = arg
+	}
+
+	result := models.RedisCommandResult{Args: args}
+	start := time.Now()
+
+	if cluster, ok := config.GetRDB().(*redis.ClusterClient); ok {
+		switch {
+		case req.Node != "":
+			// 指定节点：直接发送到该节点
+			client, err := redisClusterNode(ctx, cluster, req.Node)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			reply, err := newRedisReply(client.Do(ctx, cmdArgs...).Result())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			result.Node = req.Node
+			result.Reply = &reply
+
+		case strings.EqualFold(args[0], "SCAN"):
+			// SCAN 的游标只在单个节点内有效，按节点分别记录游标
+			reply, err := scanRedisCluster(ctx, cluster, args)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			result.Reply = &reply
+
+		case clusterFanoutCommands[strings.ToUpper(args[0])]:
+			// 无键命令：在每个主节点上执行
+			var mu sync.Mutex
+			err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+				reply, err := newRedisReply(client.Do(ctx, cmdArgs...).Result())
+				if err != nil {
+					return err
+				}
+				mu.Lock()
+				result.Nodes = append(result.Nodes, models.RedisNodeReply{
+					Node:  client.Options().Addr,
+					Reply: reply,
+				})
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			sort.Slice(result.Nodes, func(i, j int) bool {
+				return result.Nodes[i].Node < result.Nodes[j].Node
+			})
+
+		default:
+			// 其余命令由集群客户端按键所在槽位路由
+			reply, err := newRedisReply(cluster.Do(ctx, cmdArgs...).Result())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			result.Reply = &reply
+		}
+	} else {
+		// 单机模式
+		reply, err := newRedisReply(config.GetRDB().Do(ctx, cmdArgs...).Result())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		result.Reply = &reply
+	}
+
+	result.Duration = time.Since(start).Milliseconds()
+	c.JSON(http.StatusOK, result)
+}
+
+// scanRedisCluster 在所有主节点上执行 SCAN，返回与单机相同的 [游标, 键列表]
+// 游标编码了每个未遍历完的节点各自的游标，传回 0 表示从头开始，返回 0 表示所有节点都已遍历完
+func scanRedisCluster(ctx context.Context, cluster *redis.ClusterClient, args []string) (models.RedisReply, error) {
+	if len(args) < 2 {
+		return models.RedisReply{}, errors.New("SCAN 需要游标参数")
+	}
+	cursors, err := decodeClusterScanCursor(args[1])
+	if err != nil {
+		return models.RedisReply{}, err
+	}
+
+	var (
+		mu      sync.Mutex
+		visited = make(map[string]bool)
+		next    = make(map[string]uint64)
+		keys    = make(map[string][]string)
+	)
+	err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		node := client.Options().Addr
+		cursor, ok := cursors[node]
+		if cursors != nil && !ok {
+			// 该节点已经遍历完
+			return nil
+		}
+
+		cmdArgs := []interface{}{"SCAN", cursor}
+		for _, arg := range args[2:] {
+			cmdArgs = append(cmdArgs, arg)
+		}
+		val, err := client.Do(ctx, cmdArgs...).Slice()
+		if err != nil {
+			return err
+		}
+		if len(val) != 2 {
+			return fmt.Errorf("节点 %s 返回了无法识别的 SCAN 结果", node)
+		}
+		nodeCursor, err := strconv.ParseUint(fmt.Sprint(val[0]), 10, 64)
+		if err != nil {
+			return fmt.Errorf("节点 %s 返回了无法识别的 SCAN 游标: %w", node, err)
+		}
+		items, _ := val[1].([]interface{})
+
+		mu.Lock()
+		defer mu.Unlock()
+		visited[node] = true
+		if nodeCursor != 0 {
+			next[node] = nodeCursor
+		}
+		for _, item := range items {
+			keys[node] = append(keys[node], fmt.Sprint(item))
+		}
+		return nil
+	})
+	if err != nil {
+		var redisErr redis.Error
+		if errors.As(err, &redisErr) {
+			return models.RedisReply{Type: "error", Value: err.Error()}, nil
+		}
+		return models.RedisReply{}, err
+	}
+	for node := range cursors {
+		if !visited[node] {
+			return models.RedisReply{}, fmt.Errorf("游标中的节点 %s 已不是集群主节点，请从 0 重新开始", node)
+		}
+	}
+
+	nodes := make([]string, 0, len(keys))
+	for node := range keys {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	elements := []models.RedisReply{}
+	for _, node := range nodes {
+		for _, key := range keys[node] {
+			elements = append(elements, models.RedisReply{Type: "bulk", Value: key})
+		}
+	}
+
+	return models.RedisReply{Type: "array", Elements: []models.RedisReply{
+		{Type: "bulk", Value: encodeClusterScanCursor(next)},
+		{Type: "array", Elements: elements},
+	}}, nil
+}
+
+// encodeClusterScanCursor 将各节点的游标编码为一个参数，没有未完成的节点时返回 0
+func encodeClusterScanCursor(cursors map[string]uint64) string {
+	if len(cursors) == 0 {
+		return "0"
+	}
+	data, _ := json.Marshal(cursors)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeClusterScanCursor 解析 encodeClusterScanCursor 生成的游标，0 返回 nil 表示所有节点从头开始
+func decodeClusterScanCursor(s string) (map[string]uint64, error) {
+	if s == "0" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	var cursors map[string]uint64
+	if err == nil {
+		err = json.Unmarshal(data, &cursors)
+	}
+	if err != nil || len(cursors) == 0 {
+		return nil, fmt.Errorf("无效的集群 SCAN 游标 %q，请使用上一次 SCAN 返回的游标或 0", s)
+	}
+	return cursors, nil
+}
+
+// checkRedisCommandAllowed 按配置的允许/禁止列表校验命令
+func checkRedisCommandAllowed(args []string) error {
+	console := config.Redis().Console
+
+	for _, rule := range connStateCommands {
+		if matchRedisCommandRule(rule, args) {
+			return fmt.Errorf("命令 %s 会改变共享连接的状态，不能在控制台执行", strings.ToUpper(rule))
+		}
+	}
+
+	denied := console.DeniedCommands
+	if denied == nil {
+		denied = defaultDeniedCommands
+	}
+
+	for _, rule := range denied {
+		if matchRedisCommandRule(rule, args) {
+			return fmt.Errorf("命令 %s 已被禁止执行", strings.ToUpper(rule))
+		}
+	}
+
+	if len(console.AllowedCommands) == 0 {
+		return nil
+	}
+	for _, rule := range console.AllowedCommands {
+		if matchRedisCommandRule(rule, args) {
+			return nil
+		}
+	}
+	return fmt.Errorf("命令 %s 不在允许列表中", strings.ToUpper(args[0]))
+}
+
+// matchRedisCommandRule 判断命令是否匹配规则，规则可包含子命令（如 CONFIG SET）
+func matchRedisCommandRule(rule string, args []string) bool {
+	parts := strings.Fields(rule)
+	if len(parts) == 0 || len(parts) > len(args) {
+		return false
+	}
+	for i, part := range parts {
+		if !strings.EqualFold(part, args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRedisCommandLine 按 redis-cli 的规则拆分命令行参数
+// 双引号内支持 \n \r \t \b \a \xHH 等转义，单引号内只支持 \'
+func parseRedisCommandLine(line string) ([]string, error) {
+	var args []string
+	i, n := 0, len(line)
+
+	for {
+		for i < n && isRedisArgSpace(line[i]) {
+			i++
+		}
+		if i >= n {
+			return args, nil
+		}
+
+		var (
+			current []byte
+			inDQ    bool
+			inSQ    bool
+			done    bool
+		)
+		for !done {
+			if i >= n {
+				if inDQ || inSQ {
+					return nil, errors.New("命令中的引号不匹配")
+				}
+				break
+			}
+
+			ch := line[i]
+			switch {
+			case inDQ:
+				if ch == '\\' && i+3 < n && line[i+1] == 'x' && isHexDigit(line[i+2]) && isHexDigit(line[i+3]) {
+					b, _ := strconv.ParseUint(line[i+2:i+4], 16, 8)
+					current = append(current, byte(b))
+					i += 3
+				} else if ch == '\\' && i+1 < n {
+					i++
+					switch line[i] {
+					case 'n':
+						current = append(current, '\n')
+					case 'r':
+						current = append(current, '\r')
+					case 't':
+						current = append(current, '\t')
+					case 'b':
+						current = append(current, '\b')
+					case 'a':
+						current = append(current, '\a')
+					default:
+						current = append(current, line[i])
+					}
+				} else if ch == '"' {
+					// 闭合引号后必须是空白或行尾
+					if i+1 < n && !isRedisArgSpace(line[i+1]) {
+						return nil, errors.New("闭合引号后必须跟空格")
+					}
+					done = true
+				} else {
+					current = append(current, ch)
+				}
+			case inSQ:
+				if ch == '\\' && i+1 < n && line[i+1] == '\'' {
+					current = append(current, '\'')
+					i++
+				} else if ch == '\'' {
+					if i+1 < n && !isRedisArgSpace(line[i+1]) {
+						return nil, errors.New("闭合引号后必须跟空格")
+					}
+					done = true
+				} else {
+					current = append(current, ch)
+				}
+			default:
+				switch ch {
+				case ' ', '\n', '\r', '\t', 0:
+					done = true
+				case '"':
+					inDQ = true
+				case '\'':
+					inSQ = true
+				default:
+					current = append(current, ch)
+				}
+			}
+			i++
+		}
+
+		args = append(args, string(current))
+	}
+}
+
+func isRedisArgSpace(ch byte) bool {
+	return ch == ' ' || ch == '\n' || ch == '\r' || ch == '\t' || ch == 0
+}
+
+func isHexDigit(ch byte) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+// newRedisReply 将 go-redis 的回复转换为带类型的树
+// 服务端返回的错误作为 error 类型的回复，网络等错误原样返回
+func newRedisReply(val interface{}, err error) (models.RedisReply, error) {
+	if err != nil {
+		if err == redis.Nil {
+			return models.RedisReply{Type: "nil"}, nil
+		}
+		var redisErr redis.Error
+		if errors.As(err, &redisErr) {
+			return models.RedisReply{Type: "error", Value: err.Error()}, nil
+		}
+		return models.RedisReply{}, err
+	}
+	return toRedisReply(val), nil
+}
+
+func toRedisReply(val interface{}) models.RedisReply {
+	switch v := val.(type) {
+	case nil:
+		return models.RedisReply{Type: "nil"}
+	case string:
+		return models.RedisReply{Type: "bulk", Value: v}
+	case int64:
+		return models.RedisReply{Type: "integer", Value: v}
+	case float64:
+		// NaN 和 Inf 无法编码为 JSON 数字，统一按字符串输出
+		return models.RedisReply{Type: "double", Value: strconv.FormatFloat(v, 'g', -1, 64)}
+	case bool:
+		return models.RedisReply{Type: "boolean", Value: v}
+	case *big.Int:
+		return models.RedisReply{Type: "bignum", Value: v.String()}
+	case error:
+		return models.RedisReply{Type: "error", Value: v.Error()}
+	case []interface{}:
+		elements := make([]models.RedisReply, len(v))
+		for i, item := range v {
+			elements[i] = toRedisReply(item)
+		}
+		return models.RedisReply{Type: "array", Elements: elements}
+	case map[interface{}]interface{}:
+		entries := make([]models.RedisReplyEntry, 0, len(v))
+		for key, value := range v {
+			entries = append(entries, models.RedisReplyEntry{
+				Key:   toRedisReply(key),
+				Value: toRedisReply(value),
+			})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return fmt.Sprint(entries[i].Key.Value) < fmt.Sprint(entries[j].Key.Value)
+		})
+		return models.RedisReply{Type: "map", Entries: entries}
+	default:
+		return models.RedisReply{Type: "bulk", Value: fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+// GetRedisInfo 获取各节点 INFO 信息及集群汇总
+func GetRedisInfo(c *gin.Context) {
+	section := c.DefaultQuery("section", "everything")
+	node := c.Query("node")
+	ctx := c.Request.Context()
+
+	nodes, err := collectRedisInfo(ctx, node, section)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RedisInfoResult{
+		Nodes:   nodes,
+		Summary: summarizeRedisInfo(nodes),
+	})
+}
+
+// GetRedisSlowLog 获取慢查询日志
+func GetRedisSlowLog(c *gin.Context) {
+	count, _ := strconv.ParseInt(c.DefaultQuery("count", "128"), 10, 64)
+	node := c.Query("node")
+	ctx := c.Request.Context()
+
+	var (
+		mu     sync.Mutex
+		result []models.RedisNodeSlowLog
+	)
+	err := forEachRedisNode(ctx, node, false, func(ctx context.Context, client *redis.Client) error {
+		logs, err := client.SlowLogGet(ctx, count).Result()
+		if err != nil {
+			return err
+		}
+
+		entries := make([]models.RedisSlowLogEntry, 0, len(logs))
+		for _, l := range logs {
+			entries = append(entries, models.RedisSlowLogEntry{
+				ID:         l.ID,
+				Time:       l.Time,
+				Duration:   l.Duration.Microseconds(),
+				Args:       l.Args,
+				ClientAddr: l.ClientAddr,
+				ClientName: l.ClientName,
+			})
+		}
+
+		mu.Lock()
+		result = append(result, models.RedisNodeSlowLog{Node: client.Options().Addr, Entries: entries})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Node < result[j].Node })
+	c.JSON(http.StatusOK, result)
+}
+
+// ResetRedisSlowLog 清空慢查询日志
+func ResetRedisSlowLog(c *gin.Context) {
+	node := c.Query("node")
+	ctx := c.Request.Context()
+
+	middleware.AuditCommand(c, "SLOWLOG RESET")
+	err := forEachRedisNode(ctx, node, false, func(ctx context.Context, client *redis.Client) error {
+		return client.Do(ctx, "slowlog", "reset").Err()
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "慢查询日志已清空"})
+}
+
+// GetRedisClients 获取客户端连接列表
+func GetRedisClients(c *gin.Context) {
+	node := c.Query("node")
+	ctx := c.Request.Context()
+
+	var (
+		mu     sync.Mutex
+		result []models.RedisNodeClients
+	)
+	err := forEachRedisNode(ctx, node, false, func(ctx context.Context, client *redis.Client) error {
+		list, err := client.ClientList(ctx).Result()
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		result = append(result, models.RedisNodeClients{
+			Node:    client.Options().Addr,
+			Clients: parseRedisClientList(list),
+		})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Node < result[j].Node })
+	c.JSON(http.StatusOK, result)
+}
+
+// KillRedisClient 断开指定客户端连接
+func KillRedisClient(c *gin.Context) {
+	var req models.RedisClientKill
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var filter []string
+	switch {
+	case req.ID > 0:
+		filter = []string{"ID", strconv.FormatInt(req.ID, 10)}
+	case req.Addr != "":
+		filter = []string{"ADDR", req.Addr}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要指定客户端 id 或 addr"})
+		return
+	}
+
+	if _, ok := config.GetRDB().(*redis.ClusterClient); ok && req.Node == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "集群模式下需要指定节点"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var killed int64
+	middleware.AuditCommand(c, "CLIENT KILL "+strings.Join(filter, " "))
+	err := forEachRedisNode(ctx, req.Node, false, func(ctx context.Context, client *redis.Client) error {
+		n, err := client.ClientKillByFilter(ctx, filter...).Result()
+		killed = n
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if killed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "客户端连接不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "客户端连接已断开", "killed": killed})
+}
+
+// GetRedisMemoryStats 获取 MEMORY STATS
+func GetRedisMemoryStats(c *gin.Context) {
+	node := c.Query("node")
+	ctx := c.Request.Context()
+
+	var (
+		mu     sync.Mutex
+		result []models.RedisNodeMemory
+	)
+	err := forEachRedisNode(ctx, node, false, func(ctx context.Context, client *redis.Client) error {
+		val, err := client.Do(ctx, "memory", "stats").Result()
+		if err != nil {
+			return err
+		}
+
+		stats, _ := plainRedisMap(val).(map[string]interface{})
+		mu.Lock()
+		result = append(result, models.RedisNodeMemory{Node: client.Options().Addr, Stats: stats})
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Node < result[j].Node })
+	c.JSON(http.StatusOK, result)
+}
+
+// GetRedisDBSize 获取各主节点键数量及总数
+func GetRedisDBSize(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var (
+		mu     sync.Mutex
+		result models.RedisDBSizeResult
+	)
+	err := forEachRedisNode(ctx, "", true, func(ctx context.Context, client *redis.Client) error {
+		n, err := client.DBSize(ctx).Result()
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		result.Nodes = append(result.Nodes, models.RedisNodeDBSize{Node: client.Options().Addr, Keys: n})
+		result.Total += n
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort.Slice(result.Nodes, func(i, j int) bool { return result.Nodes[i].Node < result.Nodes[j].Node })
+	c.JSON(http.StatusOK, result)
+}
+
+// collectRedisInfo 在各节点执行 INFO 并解析
+func collectRedisInfo(ctx context.Context, node string, sections ...string) ([]models.RedisNodeInfo, error) {
+	var (
+		mu    sync.Mutex
+		nodes []models.RedisNodeInfo
+	)
+	err := forEachRedisNode(ctx, node, false, func(ctx context.Context, client *redis.Client) error {
+		raw, err := client.Info(ctx, sections...).Result()
+		if err != nil {
+			return err
+		}
+
+		parsed := parseRedisInfo(raw)
+		info := models.RedisNodeInfo{
+			Node:     client.Options().Addr,
+			Role:     parsed["replication"]["role"],
+			Sections: parsed,
+		}
+		if info.Role == "" {
+			// 未请求 replication 段时单独查询角色
+			repl, err := client.Info(ctx, "replication").Result()
+			if err == nil {
+				info.Role = parseRedisInfo(repl)["replication"]["role"]
+			}
+		}
+
+		mu.Lock()
+		nodes = append(nodes, info)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	return nodes, nil
+}
+
+// parseRedisInfo 将 INFO 文本解析为 段名 -> 字段 -> 值
+func parseRedisInfo(raw string) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	current := "default"
+
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			current = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if sections[current] == nil {
+			sections[current] = make(map[string]string)
+		}
+		sections[current][key] = value
+	}
+	return sections
+}
+
+// summarizeRedisInfo 汇总各节点指标
+func summarizeRedisInfo(nodes []models.RedisNodeInfo) models.RedisSummary {
+	summary := models.RedisSummary{
+		Mode:  config.Redis().Mode,
+		Nodes: len(nodes),
+	}
+	if summary.Mode == "" {
+		summary.Mode = "single"
+	}
+
+	versions := make(map[string]bool)
+	for _, node := range nodes {
+		s := node.Sections
+		if v := s["server"]["redis_version"]; v != "" && !versions[v] {
+			versions[v] = true
+			summary.Versions = append(summary.Versions, v)
+		}
+
+		summary.ConnectedClients += infoInt(s, "clients", "connected_clients")
+		summary.BlockedClients += infoInt(s, "clients", "blocked_clients")
+		summary.UsedMemory += infoInt(s, "memory", "used_memory")
+		summary.MaxMemory += infoInt(s, "memory", "maxmemory")
+		summary.OpsPerSec += infoInt(s, "stats", "instantaneous_ops_per_sec")
+		summary.KeyspaceHits += infoInt(s, "stats", "keyspace_hits")
+		summary.KeyspaceMisses += infoInt(s, "stats", "keyspace_misses")
+
+		if node.Role == "slave" {
+			summary.Replicas++
+			continue
+		}
+		summary.Masters++
+
+		// 键数量只统计主节点，避免从节点重复计算
+		for _, value := range s["keyspace"] {
+			for _, field := range strings.Split(value, ",") {
+				k, v, _ := strings.Cut(field, "=")
+				n, _ := strconv.ParseInt(v, 10, 64)
+				switch k {
+				case "keys":
+					summary.Keys += n
+				case "expires":
+					summary.Expires += n
+				}
+			}
+		}
+	}
+
+	sort.Strings(summary.Versions)
+	if total := summary.KeyspaceHits + summary.KeyspaceMisses; total > 0 {
+		summary.HitRate = float64(summary.KeyspaceHits) / float64(total)
+	}
+	return summary
+}
+
+func infoInt(sections map[string]map[string]string, section, key string) int64 {
+	n, _ := strconv.ParseInt(sections[section][key], 10, 64)
+	return n
+}
+
+// parseRedisClientList 解析 CLIENT LIST 输出
+func parseRedisClientList(raw string) []map[string]string {
+	clients := []map[string]string{}
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		client := make(map[string]string)
+		for _, field := range strings.Fields(line) {
+			k, v, _ := strings.Cut(field, "=")
+			client[k] = v
+		}
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// plainRedisMap 将 Redis 回复转换为可编码为 JSON 的值
+// RESP2 下以扁平数组返回的键值对也会转换为 map
+func plainRedisMap(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = plainRedisMap(value)
+		}
+		return m
+	case []interface{}:
+		if len(v)%2 == 0 && isRedisFieldList(v) {
+			m := make(map[string]interface{}, len(v)/2)
+			for i := 0; i < len(v); i += 2 {
+				m[v[i].(string)] = plainRedisMap(v[i+1])
+			}
+			return m
+		}
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = plainRedisMap(item)
+		}
+		return list
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return v
+	case *big.Int:
+		return v.String()
+	case error:
+		return v.Error()
+	default:
+		return v
+	}
+}
+
+// isRedisFieldList 判断数组是否为 字段名, 值, 字段名, 值... 的形式
+func isRedisFieldList(v []interface{}) bool {
+	if len(v) == 0 {
+		return false
+	}
+	for i := 0; i < len(v); i += 2 {
+		if _, ok := v[i].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
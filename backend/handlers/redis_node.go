@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wgcoder2024/go-web/backend/config"
+)
+
+// redisClusterNode 按地址查找集群中的节点（包括从节点）
+func redisClusterNode(ctx context.Context, cluster *redis.ClusterClient, addr string) (*redis.Client, error) {
+	var (
+		mu    sync.Mutex
+		found *redis.Client
+	)
+
+	err := cluster.ForEachShard(ctx, func(ctx context.Context, client *redis.Client) error {
+		if client.Options().Addr == addr {
+			mu.Lock()
+			found = client
+			mu.Unlock()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("集群中不存在节点 %s", addr)
+	}
+	return found, nil
+}
+
+// forEachRedisNode 在当前 Redis 客户端的节点上执行 fn
+// node 非空时只访问该节点；集群模式下 mastersOnly 控制是否跳过从节点
+// 集群模式下 fn 会被并发调用，调用方需要自行加锁
+func forEachRedisNode(ctx context.Context, node string, mastersOnly bool, fn func(ctx context.Context, client *redis.Client) error) error {
+	return forEachRedisNodeOf(ctx, config.GetRDB(), node, mastersOnly, fn)
+}
+
+// forEachRedisNodeOf 与 forEachRedisNode 相同，但使用指定的客户端（如 config.AcquireRDB 取得的客户端）
+func forEachRedisNodeOf(ctx context.Context, client redis.UniversalClient, node string, mastersOnly bool, fn func(ctx context.Context, client *redis.Client) error) error {
+	switch rdb := client.(type) {
+	case *redis.ClusterClient:
+		if node != "" {
+			client, err := redisClusterNode(ctx, rdb, node)
+			if err != nil {
+				return err
+			}
+			return fn(ctx, client)
+		}
+		if mastersOnly {
+			return rdb.ForEachMaster(ctx, fn)
+		}
+		return rdb.ForEachShard(ctx, fn)
+	case *redis.Client:
+		return fn(ctx, rdb)
+	default:
+		return fmt.Errorf("不支持的 Redis 客户端类型 %T", client)
+	}
+}
+
+// scanRedisKeys 使用 SCAN 遍历 rdb 所有主节点上匹配 pattern 的键，每批键回调一次 fn
+// 集群模式下各主节点并发扫描，fn 需要自行保证并发安全
+// 扫描可能超过旧客户端的关闭等待时间，调用方应通过 config.AcquireRDB 取得 rdb
+func scanRedisKeys(ctx context.Context, rdb redis.UniversalClient, pattern string, count int64, fn func(ctx context.Context, client *redis.Client, keys []string) error) error {
+	return forEachRedisNodeOf(ctx, rdb, "", true, func(ctx context.Context, client *redis.Client) error {
+		var cursor uint64
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			keys, next, err := client.Scan(ctx, cursor, pattern, count).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				if err := fn(ctx, client, keys); err != nil {
+					return err
+				}
+			}
+
+			cursor = next
+			if cursor == 0 {
+				return nil
+			}
+		}
+	})
+}
@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsPingInterval = 30 * time.Second
+	wsReadLimit    = 1 << 20
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     checkWSOrigin,
+}
+
+// checkWSOrigin 只允许 CORS 配置中的来源建立 WebSocket 连接
+func checkWSOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range config.Server().Cors.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// redisPubSubSession 一个 WebSocket 连接对应的订阅会话
+type redisPubSubSession struct {
+	conn   *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+	send   chan models.RedisPubSubFrame
+	wg     sync.WaitGroup
+	// closeMsg 关闭连接时发送的关闭帧，未设置时为正常关闭
+	closeMsg atomic.Pointer[[]byte]
+
+	// rdb 会话建立时的 Redis 客户端，会话内的订阅和发布都使用它，配置重载替换客户端后会话被关闭
+	rdb redis.UniversalClient
+
+	// allowed 检查当前用户的权限，路由只要求 redis.read，发布消息需要对频道有 redis.write
+	allowed func(perm, resource string) (bool, error)
+
+	// pubsub 普通频道和模式订阅
+	pubsub *redis.PubSub
+	// nodeSubs 集群模式下键空间通知只在键所在节点发布，需要在每个主节点上单独订阅
+	nodeSubs map[string]*redis.PubSub
+}
+
+var (
+	redisPubSubMu       sync.Mutex
+	redisPubSubSessions = make(map[*redisPubSubSession]struct{})
+)
+
+// CloseRedisPubSubSessions 关闭所有订阅会话，WebSocket 连接已被接管，http.Server.Shutdown 不会等待它们
+func CloseRedisPubSubSessions() {
+	redisPubSubMu.Lock()
+	defer redisPubSubMu.Unlock()
+
+	for s := range redisPubSubSessions {
+		s.closeWith(websocket.CloseGoingAway, "服务正在关闭")
+	}
+}
+
+// CloseStaleRedisPubSubSessions 关闭仍在使用旧 Redis 客户端的会话，在配置重载替换客户端后调用
+// 客户端收到 1012（Service Restart）关闭帧后应重新连接并订阅
+func CloseStaleRedisPubSubSessions() {
+	rdb := config.GetRDB()
+
+	redisPubSubMu.Lock()
+	defer redisPubSubMu.Unlock()
+
+	for s := range redisPubSubSessions {
+		if s.rdb != rdb {
+			s.closeWith(websocket.CloseServiceRestart, "Redis 配置已变更，请重新连接")
+		}
+	}
+}
+
+// RedisPubSub 通过 WebSocket 订阅频道、键空间通知并发布消息
+func RedisPubSub(c *gin.Context) {
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已经写入了错误响应
+		return
+	}
+
+	// 持有客户端直到会话结束，订阅连接不会在替换后的延迟关闭中被直接断开
+	rdb, release := config.AcquireRDB()
+	defer release()
+
+	// 会话在 HTTP 请求结束后继续运行，只沿用请求 ID 等值
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.Request.Context()))
+	s := &redisPubSubSession{
+		rdb:      rdb,
+		conn:     conn,
+		ctx:      ctx,
+		cancel:   cancel,
+		send:     make(chan models.RedisPubSubFrame, 256),
+		nodeSubs: make(map[string]*redis.PubSub),
+		allowed: func(perm, resource string) (bool, error) {
+			return middleware.Allowed(c, perm, resource)
+		},
+	}
+
+	redisPubSubMu.Lock()
+	redisPubSubSessions[s] = struct{}{}
+	redisPubSubMu.Unlock()
+	defer func() {
+		redisPubSubMu.Lock()
+		delete(redisPubSubSessions, s)
+		redisPubSubMu.Unlock()
+	}()
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		s.writeLoop()
+	}()
+
+	s.readLoop()
+
+	// 连接断开：取消订阅并释放 Redis 连接
+	cancel()
+	s.closeSubscriptions()
+	s.wg.Wait()
+	<-writerDone
+	conn.Close()
+}
+
+func (s *redisPubSubSession) readLoop() {
+	s.conn.SetReadLimit(wsReadLimit)
+	s.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		var req models.RedisPubSubRequest
+		if err := s.conn.ReadJSON(&req); err != nil {
+			// JSON 格式错误时提示客户端，其余错误说明连接已断开
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				s.push(models.RedisPubSubFrame{Type: "error", Error: err.Error()})
+				continue
+			}
+			return
+		}
+		s.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+
+		if err := s.handle(req); err != nil {
+			s.push(models.RedisPubSubFrame{Type: "error", Error: err.Error()})
+		}
+	}
+}
+
+func (s *redisPubSubSession) writeLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+			if m := s.closeMsg.Load(); m != nil {
+				msg = *m
+			}
+			s.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+			// 服务端主动关闭时，客户端未回应关闭帧也要结束 readLoop
+			s.conn.SetReadDeadline(time.Now().Add(wsWriteTimeout))
+			return
+		case frame := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := s.conn.WriteJSON(frame); err != nil {
+				// 关闭连接以结束阻塞中的 readLoop
+				s.cancel()
+				s.conn.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				s.cancel()
+				s.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (s *redisPubSubSession) handle(req models.RedisPubSubRequest) error {
+	ctx := s.ctx
+
+	switch req.Action {
+	case "subscribe":
+		if len(req.Channels) == 0 {
+			return fmt.Errorf("channels 不能为空")
+		}
+		if s.pubsub == nil {
+			s.pubsub = s.rdb.Subscribe(ctx, req.Channels...)
+			s.pump(s.pubsub, "")
+		} else if err := s.pubsub.Subscribe(ctx, req.Channels...); err != nil {
+			return err
+		}
+		s.push(models.RedisPubSubFrame{Type: "subscribed", Channels: req.Channels})
+
+	case "psubscribe":
+		if len(req.Channels) == 0 {
+			return fmt.Errorf("channels 不能为空")
+		}
+		if err := s.psubscribe(req.Channels); err != nil {
+			return err
+		}
+		s.push(models.RedisPubSubFrame{Type: "subscribed", Channels: req.Channels})
+
+	case "keyspace":
+		// 键空间通知：__keyspace@<db>__:<key pattern>，键事件通知：__keyevent@<db>__:<event>
+		pattern := req.Pattern
+		if pattern == "" {
+			pattern = "*"
+		}
+		prefix := "__keyspace@%d__:"
+		if req.Events {
+			prefix = "__keyevent@%d__:"
+		}
+		channel := fmt.Sprintf(prefix, req.DB) + pattern
+
+		if err := s.subscribeKeyspace(channel); err != nil {
+			return err
+		}
+		s.push(models.RedisPubSubFrame{Type: "subscribed", Channels: []string{channel}})
+		s.checkKeyspaceEvents()
+
+	case "unsubscribe":
+		if s.pubsub != nil {
+			if err := s.pubsub.Unsubscribe(ctx, req.Channels...); err != nil {
+				return err
+			}
+		}
+		s.push(models.RedisPubSubFrame{Type: "unsubscribed", Channels: req.Channels})
+
+	case "punsubscribe":
+		if s.pubsub != nil {
+			if err := s.pubsub.PUnsubscribe(ctx, req.Channels...); err != nil {
+				return err
+			}
+		}
+		for _, ps := range s.nodeSubs {
+			if err := ps.PUnsubscribe(ctx, req.Channels...); err != nil {
+				return err
+			}
+		}
+		s.push(models.RedisPubSubFrame{Type: "unsubscribed", Channels: req.Channels})
+
+	case "publish":
+		if req.Channel == "" {
+			return fmt.Errorf("channel 不能为空")
+		}
+		ok, err := s.allowed("redis.write", req.Channel)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("没有向频道 %s 发布消息的权限（需要 redis.write）", req.Channel)
+		}
+		n, err := s.rdb.Publish(ctx, req.Channel, req.Message).Result()
+		if err != nil {
+			return err
+		}
+		s.push(models.RedisPubSubFrame{Type: "published", Channel: req.Channel, Receivers: &n})
+
+	case "ping":
+		s.push(models.RedisPubSubFrame{Type: "pong"})
+
+	default:
+		return fmt.Errorf("不支持的操作 %q", req.Action)
+	}
+	return nil
+}
+
+func (s *redisPubSubSession) psubscribe(patterns []string) error {
+	if s.pubsub == nil {
+		s.pubsub = s.rdb.PSubscribe(s.ctx, patterns...)
+		s.pump(s.pubsub, "")
+		return nil
+	}
+	return s.pubsub.PSubscribe(s.ctx, patterns...)
+}
+
+// subscribeKeyspace 订阅键空间通知，集群模式下在每个主节点上分别订阅
+func (s *redisPubSubSession) subscribeKeyspace(channel string) error {
+	cluster, ok := s.rdb.(*redis.ClusterClient)
+	if !ok {
+		return s.psubscribe([]string{channel})
+	}
+
+	var (
+		mu      sync.Mutex
+		clients []*redis.Client
+	)
+	err := cluster.ForEachMaster(s.ctx, func(ctx context.Context, client *redis.Client) error {
+		mu.Lock()
+		clients = append(clients, client)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, client := range clients {
+		node := client.Options().Addr
+		if ps, ok := s.nodeSubs[node]; ok {
+			if err := ps.PSubscribe(s.ctx, channel); err != nil {
+				return err
+			}
+			continue
+		}
+		ps := client.PSubscribe(s.ctx, channel)
+		s.nodeSubs[node] = ps
+		s.pump(ps, node)
+	}
+	return nil
+}
+
+// checkKeyspaceEvents 服务器未开启 notify-keyspace-events 时提醒客户端
+func (s *redisPubSubSession) checkKeyspaceEvents() {
+	var (
+		mu       sync.Mutex
+		disabled []string
+	)
+	forEachRedisNodeOf(s.ctx, s.rdb, "", true, func(ctx context.Context, client *redis.Client) error {
+		val, err := client.ConfigGet(ctx, "notify-keyspace-events").Result()
+		if err == nil && val["notify-keyspace-events"] == "" {
+			mu.Lock()
+			disabled = append(disabled, client.Options().Addr)
+			mu.Unlock()
+		}
+		return nil
+	})
+
+	if len(disabled) > 0 {
+		s.push(models.RedisPubSubFrame{
+			Type:     "warning",
+			Error:    "以下节点未开启 notify-keyspace-events，无法收到键空间通知",
+			Channels: disabled,
+		})
+	}
+}
+
+// pump 将订阅收到的消息转发到 WebSocket
+func (s *redisPubSubSession) pump(ps *redis.PubSub, node string) {
+	ch := ps.Channel()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for msg := range ch {
+			frame := models.RedisPubSubFrame{
+				Type:    "message",
+				Channel: msg.Channel,
+				Pattern: msg.Pattern,
+				Payload: msg.Payload,
+				Node:    node,
+			}
+			if msg.Pattern != "" {
+				frame.Type = "pmessage"
+			}
+			if !s.push(frame) {
+				return
+			}
+		}
+		// 会话仍在运行时订阅连接被关闭，通知客户端重新连接
+		if s.ctx.Err() == nil {
+			s.closeWith(websocket.CloseTryAgainLater, "Redis 订阅连接已断开，请重新连接")
+		}
+	}()
+}
+
+// closeWith 以指定的关闭码结束会话，只有第一次调用的关闭码会发送给客户端
+func (s *redisPubSubSession) closeWith(code int, text string) {
+	msg := websocket.FormatCloseMessage(code, text)
+	s.closeMsg.CompareAndSwap(nil, &msg)
+	s.cancel()
+}
+
+// push 将消息帧放入发送队列，会话结束时返回 false
+func (s *redisPubSubSession) push(frame models.RedisPubSubFrame) bool {
+	frame.Time = time.Now()
+	select {
+	case s.send <- frame:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+func (s *redisPubSubSession) closeSubscriptions() {
+	if s.pubsub != nil {
+		s.pubsub.Close()
+	}
+	for _, ps := range s.nodeSubs {
+		ps.Close()
+	}
+}
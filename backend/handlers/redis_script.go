@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetRedisScripts 获取脚本列表
+func GetRedisScripts(c *gin.Context) {
+	var scripts []models.RedisScript
+	if err := config.GetDB().WithContext(c).Order("name").Find(&scripts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, scripts)
+}
+
+// GetRedisScript 获取脚本详情及各节点加载状态
+func GetRedisScript(c *gin.Context) {
+	script, ok := findRedisScript(c)
+	if !ok {
+		return
+	}
+
+	nodes, err := redisScriptStatus(c.Request.Context(), script)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RedisScriptDetail{RedisScript: *script, Nodes: nodes})
+}
+
+// CreateRedisScript 保存脚本
+func CreateRedisScript(c *gin.Context) {
+	var script models.RedisScript
+	if err := c.ShouldBindJSON(&script); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	script.ID = 0
+	script.SHA1 = redis.NewScript(script.Body).Hash()
+	middleware.AuditCommand(c, script.Body)
+	if err := config.GetDB().WithContext(c).Create(&script).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, script)
+}
+
+// UpdateRedisScript 修改脚本内容
+func UpdateRedisScript(c *gin.Context) {
+	script, ok := findRedisScript(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Description string `json:"description"`
+		Body        string `json:"body" binding:"required"`
+		ReadOnly    bool   `json:"readOnly"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	script.Description = req.Description
+	script.Body = req.Body
+	script.ReadOnly = req.ReadOnly
+	script.SHA1 = redis.NewScript(req.Body).Hash()
+	middleware.AuditCommand(c, req.Body)
+	if err := config.GetDB().WithContext(c).Save(script).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, script)
+}
+
+// DeleteRedisScript 删除脚本
+func DeleteRedisScript(c *gin.Context) {
+	script, ok := findRedisScript(c)
+	if !ok {
+		return
+	}
+
+	middleware.AuditCommand(c, script.Body)
+	if err := config.GetDB().WithContext(c).Delete(script).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "脚本删除成功"})
+}
+
+// LoadRedisScript 在所有节点（包括从节点）上执行 SCRIPT LOAD
+func LoadRedisScript(c *gin.Context) {
+	script, ok := findRedisScript(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	middleware.AuditCommand(c, formatRedisArgs([]interface{}{"SCRIPT", "LOAD", script.Body}))
+	err := forEachRedisNode(ctx, "", false, func(ctx context.Context, client *redis.Client) error {
+		return client.ScriptLoad(ctx, script.Body).Err()
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	nodes, err := redisScriptStatus(ctx, script)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RedisScriptDetail{RedisScript: *script, Nodes: nodes})
+}
+
+// RunRedisScript 通过 EVALSHA 执行脚本，节点上未加载时自动回退到 EVAL
+func RunRedisScript(c *gin.Context) {
+	script, ok := findRedisScript(c)
+	if !ok {
+		return
+	}
+
+	var req models.RedisScriptRun
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	s := redis.NewScript(script.Body)
+	var cmd *redis.Cmd
+	if script.ReadOnly {
+		cmd = s.RunRO(ctx, config.GetRDB(), req.Keys, toInterfaces(req.Args)...)
+	} else {
+		cmd = s.Run(ctx, config.GetRDB(), req.Keys, toInterfaces(req.Args)...)
+	}
+	middleware.AuditCommand(c, formatRedisArgs(cmd.Args()))
+
+	reply, err := newRedisReply(cmd.Result())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reply)
+}
+
+// GetRedisFunctions 获取各主节点上的函数库
+func GetRedisFunctions(c *gin.Context) {
+	query := redis.FunctionListQuery{
+		LibraryNamePattern: c.Query("library"),
+		WithCode:           c.Query("withCode") == "true",
+	}
+	ctx := c.Request.Context()
+
+	var (
+		mu     sync.Mutex
+		result []models.RedisNodeFunctions
+	)
+	err := forEachRedisNode(ctx, "", true, func(ctx context.Context, client *redis.Client) error {
+		libs, err := client.FunctionList(ctx, query).Result()
+		if err != nil {
+			return err
+		}
+
+		node := models.RedisNodeFunctions{
+			Node:      client.Options().Addr,
+			Libraries: make([]models.RedisFunctionLibrary, 0, len(libs)),
+		}
+		for _, lib := range libs {
+			library := models.RedisFunctionLibrary{Name: lib.Name, Engine: lib.Engine, Code: lib.Code}
+			for _, fn := range lib.Functions {
+				library.Functions = append(library.Functions, models.RedisFunctionInfo{
+					Name:        fn.Name,
+					Description: fn.Description,
+					Flags:       fn.Flags,
+				})
+			}
+			node.Libraries = append(node.Libraries, library)
+		}
+
+		mu.Lock()
+		result = append(result, node)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Node < result[j].Node })
+	c.JSON(http.StatusOK, result)
+}
+
+// LoadRedisFunction 在所有主节点上执行 FUNCTION LOAD
+func LoadRedisFunction(c *gin.Context) {
+	var req models.RedisFunctionLoad
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	args := []interface{}{"FUNCTION", "LOAD"}
+	if req.Replace {
+		args = append(args, "REPLACE")
+	}
+	middleware.AuditCommand(c, formatRedisArgs(append(args, req.Code)))
+
+	ctx := c.Request.Context()
+	var (
+		mu      sync.Mutex
+		library string
+	)
+	err := forEachRedisNode(ctx, "", true, func(ctx context.Context, client *redis.Client) error {
+		var cmd *redis.StringCmd
+		if req.Replace {
+			cmd = client.FunctionLoadReplace(ctx, req.Code)
+		} else {
+			cmd = client.FunctionLoad(ctx, req.Code)
+		}
+		if err := cmd.Err(); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		library = cmd.Val()
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "函数库加载成功", "library": library})
+}
+
+// DeleteRedisFunction 在所有主节点上删除函数库
+func DeleteRedisFunction(c *gin.Context) {
+	library := c.Param("library")
+	ctx := c.Request.Context()
+	middleware.AuditCommand(c, formatRedisArgs([]interface{}{"FUNCTION", "DELETE", library}))
+
+	err := forEachRedisNode(ctx, "", true, func(ctx context.Context, client *redis.Client) error {
+		return client.FunctionDelete(ctx, library).Err()
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "函数库删除成功"})
+}
+
+// CallRedisFunction 执行 FCALL / FCALL_RO
+func CallRedisFunction(c *gin.Context) {
+	var req models.RedisFunctionCall
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var cmd *redis.Cmd
+	if req.ReadOnly {
+		cmd = config.GetRDB().FCallRO(ctx, req.Function, req.Keys, toInterfaces(req.Args)...)
+	} else {
+		cmd = config.GetRDB().FCall(ctx, req.Function, req.Keys, toInterfaces(req.Args)...)
+	}
+	middleware.AuditCommand(c, formatRedisArgs(cmd.Args()))
+
+	reply, err := newRedisReply(cmd.Result())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reply)
+}
+
+// findRedisScript 按路径参数 name 查找脚本，找不到时直接写入响应
+func findRedisScript(c *gin.Context) (*models.RedisScript, bool) {
+	var script models.RedisScript
+	err := config.GetDB().WithContext(c).Where("name = ?", c.Param("name")).First(&script).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "脚本不存在"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &script, true
+}
+
+// redisScriptStatus 通过 SCRIPT EXISTS 检查脚本在各节点（包括从节点）上的加载状态
+func redisScriptStatus(ctx context.Context, script *models.RedisScript) ([]models.RedisScriptNodeStatus, error) {
+	var (
+		mu    sync.Mutex
+		nodes []models.RedisScriptNodeStatus
+	)
+	err := forEachRedisNode(ctx, "", false, func(ctx context.Context, client *redis.Client) error {
+		status := models.RedisScriptNodeStatus{Node: client.Options().Addr}
+		exists, err := client.ScriptExists(ctx, script.SHA1).Result()
+		if err != nil {
+			status.Error = err.Error()
+		} else {
+			status.Loaded = len(exists) > 0 && exists[0]
+		}
+
+		mu.Lock()
+		nodes = append(nodes, status)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	return nodes, nil
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+// GetRedisStream 获取 Stream 信息（XINFO STREAM）
+func GetRedisStream(c *gin.Context) {
+	key := c.Param("key")
+	ctx := c.Request.Context()
+
+	info, err := config.GetRDB().XInfoStream(ctx, key).Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result := models.RedisStreamInfo{
+		Length:               info.Length,
+		RadixTreeKeys:        info.RadixTreeKeys,
+		RadixTreeNodes:       info.RadixTreeNodes,
+		Groups:               info.Groups,
+		LastGeneratedID:      info.LastGeneratedID,
+		MaxDeletedEntryID:    info.MaxDeletedEntryID,
+		EntriesAdded:         info.EntriesAdded,
+		RecordedFirstEntryID: info.RecordedFirstEntryID,
+	}
+	if info.FirstEntry.ID != "" {
+		result.FirstEntry = &models.RedisStreamEntry{ID: info.FirstEntry.ID, Values: info.FirstEntry.Values}
+	}
+	if info.LastEntry.ID != "" {
+		result.LastEntry = &models.RedisStreamEntry{ID: info.LastEntry.ID, Values: info.LastEntry.Values}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// TrimRedisStream 按 MAXLEN 或 MINID 裁剪 Stream
+func TrimRedisStream(c *gin.Context) {
+	key := c.Param("key")
+	var req models.RedisStreamTrim
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var cmd *redis.IntCmd
+	switch req.Strategy {
+	case "maxlen":
+		maxLen, err := strconv.ParseInt(req.Threshold, 10, 64)
+		if err != nil || maxLen < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "maxlen 必须是非负整数"})
+			return
+		}
+		if req.Approx {
+			cmd = config.GetRDB().XTrimMaxLenApprox(ctx, key, maxLen, req.Limit)
+		} else {
+			cmd = config.GetRDB().XTrimMaxLen(ctx, key, maxLen)
+		}
+	case "minid":
+		if req.Approx {
+			cmd = config.GetRDB().XTrimMinIDApprox(ctx, key, req.Threshold, req.Limit)
+		} else {
+			cmd = config.GetRDB().XTrimMinID(ctx, key, req.Threshold)
+		}
+	}
+
+	middleware.AuditCommand(c, formatRedisArgs(cmd.Args()))
+	deleted, err := cmd.Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Stream 裁剪成功", "deleted": deleted})
+}
+
+// GetRedisStreamGroups 获取消费者组列表（XINFO GROUPS）
+func GetRedisStreamGroups(c *gin.Context) {
+	key := c.Param("key")
+	ctx := c.Request.Context()
+
+	groups, err := config.GetRDB().XInfoGroups(ctx, key).Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]models.RedisStreamGroup, len(groups))
+	for i, g := range groups {
+		result[i] = models.RedisStreamGroup{
+			Name:            g.Name,
+			Consumers:       g.Consumers,
+			Pending:         g.Pending,
+			LastDeliveredID: g.LastDeliveredID,
+			EntriesRead:     g.EntriesRead,
+			Lag:             g.Lag,
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateRedisStreamGroup 创建消费者组
+func CreateRedisStreamGroup(c *gin.Context) {
+	key := c.Param("key")
+	var req models.RedisStreamGroupCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Start == "" {
+		req.Start = "$"
+	}
+
+	ctx := c.Request.Context()
+	var cmd *redis.StatusCmd
+	if req.MkStream {
+		cmd = config.GetRDB().XGroupCreateMkStream(ctx, key, req.Group, req.Start)
+	} else {
+		cmd = config.GetRDB().XGroupCreate(ctx, key, req.Group, req.Start)
+	}
+	middleware.AuditCommand(c, formatRedisArgs(cmd.Args()))
+	if err := cmd.Err(); err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "消费者组创建成功"})
+}
+
+// DeleteRedisStreamGroup 删除消费者组
+func DeleteRedisStreamGroup(c *gin.Context) {
+	key := c.Param("key")
+	group := c.Param("group")
+	ctx := c.Request.Context()
+
+	cmd := config.GetRDB().XGroupDestroy(ctx, key, group)
+	middleware.AuditCommand(c, formatRedisArgs(cmd.Args()))
+	n, err := cmd.Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "消费者组不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "消费者组删除成功"})
+}
+
+// GetRedisStreamConsumers 获取消费者列表（XINFO CONSUMERS）
+func GetRedisStreamConsumers(c *gin.Context) {
+	key := c.Param("key")
+	group := c.Param("group")
+	ctx := c.Request.Context()
+
+	consumers, err := config.GetRDB().XInfoConsumers(ctx, key, group).Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]models.RedisStreamConsumer, len(consumers))
+	for i, consumer := range consumers {
+		result[i] = models.RedisStreamConsumer{
+			Name:     consumer.Name,
+			Pending:  consumer.Pending,
+			Idle:     consumer.Idle.Milliseconds(),
+			Inactive: consumer.Inactive.Milliseconds(),
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteRedisStreamConsumer 删除消费者，其待确认消息会一并丢弃
+func DeleteRedisStreamConsumer(c *gin.Context) {
+	key := c.Param("key")
+	group := c.Param("group")
+	consumer := c.Param("consumer")
+	ctx := c.Request.Context()
+
+	cmd := config.GetRDB().XGroupDelConsumer(ctx, key, group, consumer)
+	middleware.AuditCommand(c, formatRedisArgs(cmd.Args()))
+	pending, err := cmd.Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "消费者删除成功", "pending": pending})
+}
+
+// GetRedisStreamPending 获取待确认消息（XPENDING），支持按空闲时间和消费者过滤
+func GetRedisStreamPending(c *gin.Context) {
+	key := c.Param("key")
+	group := c.Param("group")
+	count, _ := strconv.ParseInt(c.DefaultQuery("count", "100"), 10, 64)
+	idle, _ := strconv.ParseInt(c.DefaultQuery("idle", "0"), 10, 64)
+	ctx := c.Request.Context()
+
+	summary, err := config.GetRDB().XPending(ctx, key, group).Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := config.GetRDB().XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   key,
+		Group:    group,
+		Idle:     time.Duration(idle) * time.Millisecond,
+		Start:    c.DefaultQuery("start", "-"),
+		End:      c.DefaultQuery("end", "+"),
+		Count:    count,
+		Consumer: c.Query("consumer"),
+	}).Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result := models.RedisStreamPending{
+		Summary: models.RedisStreamPendingSummary{
+			Count:     summary.Count,
+			Lower:     summary.Lower,
+			Higher:    summary.Higher,
+			Consumers: summary.Consumers,
+		},
+		Entries: make([]models.RedisStreamPendingEntry, len(entries)),
+	}
+	for i, e := range entries {
+		result.Entries[i] = models.RedisStreamPendingEntry{
+			ID:         e.ID,
+			Consumer:   e.Consumer,
+			Idle:       e.Idle.Milliseconds(),
+			RetryCount: e.RetryCount,
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ClaimRedisStreamMessages 将指定消息转移给其他消费者（XCLAIM）
+func ClaimRedisStreamMessages(c *gin.Context) {
+	key := c.Param("key")
+	group := c.Param("group")
+	var req models.RedisStreamClaim
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	cmd := config.GetRDB().XClaim(ctx, &redis.XClaimArgs{
+		Stream:   key,
+		Group:    group,
+		Consumer: req.Consumer,
+		MinIdle:  time.Duration(req.MinIdle) * time.Millisecond,
+		Messages: req.IDs,
+	})
+	middleware.AuditCommand(c, formatRedisArgs(cmd.Args()))
+	messages, err := cmd.Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toRedisStreamEntries(messages))
+}
+
+// AutoClaimRedisStreamMessages 自动转移空闲超时的消息（XAUTOCLAIM）
+func AutoClaimRedisStreamMessages(c *gin.Context) {
+	key := c.Param("key")
+	group := c.Param("group")
+	var req models.RedisStreamAutoClaim
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Start == "" {
+		req.Start = "0-0"
+	}
+	if req.Count <= 0 {
+		req.Count = 100
+	}
+
+	ctx := c.Request.Context()
+	cmd := config.GetRDB().XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   key,
+		Group:    group,
+		Consumer: req.Consumer,
+		MinIdle:  time.Duration(req.MinIdle) * time.Millisecond,
+		Start:    req.Start,
+		Count:    req.Count,
+	})
+	middleware.AuditCommand(c, formatRedisArgs(cmd.Args()))
+	messages, next, err := cmd.Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RedisStreamAutoClaimResult{
+		Messages:  toRedisStreamEntries(messages),
+		NextStart: next,
+	})
+}
+
+// AckRedisStreamMessages 确认消息（XACK）
+func AckRedisStreamMessages(c *gin.Context) {
+	key := c.Param("key")
+	group := c.Param("group")
+	var req models.RedisStreamAck
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	cmd := config.GetRDB().XAck(ctx, key, group, req.IDs...)
+	middleware.AuditCommand(c, formatRedisArgs(cmd.Args()))
+	acked, err := cmd.Result()
+	if err != nil {
+		c.JSON(redisStreamErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "消息确认成功", "acked": acked})
+}
+
+func toRedisStreamEntries(messages []redis.XMessage) []models.RedisStreamEntry {
+	entries := make([]models.RedisStreamEntry, len(messages))
+	for i, m := range messages {
+		entries[i] = models.RedisStreamEntry{ID: m.ID, Values: m.Values}
+	}
+	return entries
+}
+
+// redisStreamErrorStatus 键或消费者组不存在、参数错误时返回 4xx，其余返回 500
+func redisStreamErrorStatus(err error) int {
+	if !isRedisReplyError(err) {
+		return http.StatusInternalServerError
+	}
+	msg := err.Error()
+	switch {
+	case err == redis.Nil, strings.Contains(msg, "no such key"), strings.HasPrefix(msg, "NOGROUP"):
+		return http.StatusNotFound
+	case strings.HasPrefix(msg, "BUSYGROUP"):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetRoles 获取角色列表及权限
+func GetRoles(c *gin.Context) {
+	var roles []models.Role
+	if err := config.GetDB().WithContext(c).Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetPermissions 获取所有可授予的权限
+func GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, middleware.Permissions)
+}
+
+// CreateRole 创建角色
+func CreateRole(c *gin.Context) {
+	var req models.RoleRequest
+	if !bindRoleRequest(c, &req) {
+		return
+	}
+
+	role := models.Role{Name: req.Name, Description: req.Description, Permissions: req.Permissions}
+	if err := config.GetDB().WithContext(c).Create(&role).Error; err != nil {
+		saveRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole 修改角色，权限整体替换
+func UpdateRole(c *gin.Context) {
+	role, ok := findRole(c)
+	if !ok {
+		return
+	}
+
+	var req models.RoleRequest
+	if !bindRoleRequest(c, &req) {
+		return
+	}
+
+	err := config.GetDB().WithContext(c).Transaction(func(tx *gorm.DB) error {
+		role.Name = req.Name
+		role.Description = req.Description
+		if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+
+		role.Permissions = req.Permissions
+		for i := range role.Permissions {
+			role.Permissions[i].ID = 0
+			role.Permissions[i].RoleID = role.ID
+		}
+		if len(role.Permissions) == 0 {
+			return nil
+		}
+		return tx.Create(&role.Permissions).Error
+	})
+	if err != nil {
+		saveRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole 删除角色，同时移除用户的该角色
+func DeleteRole(c *gin.Context) {
+	role, ok := findRole(c)
+	if !ok {
+		return
+	}
+
+	middleware.AuditCommand(c, "DELETE ROLE "+role.Name)
+	err := config.GetDB().WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(role).Error
+	})
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
+// GetUserRoles 获取用户的角色
+func GetUserRoles(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	var user models.User
+	if err := config.GetDB().WithContext(c).First(&user, id).Error; err != nil {
+		userError(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+		return
+	}
+
+	roles, err := userRoles(c, user.ID)
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// SetUserRoles 替换用户的角色
+func SetUserRoles(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	var user models.User
+	if err := config.GetDB().WithContext(c).First(&user, id).Error; err != nil {
+		userError(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+		return
+	}
+
+	var req models.UserRolesUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		userError(c, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	var roles []models.Role
+	if len(req.Roles) > 0 {
+		if err := config.GetDB().WithContext(c).Where("name IN ?", req.Roles).Find(&roles).Error; err != nil {
+			userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+	}
+	found := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		found[role.Name] = true
+	}
+	for _, name := range req.Roles {
+		if !found[name] {
+			userError(c, http.StatusBadRequest, "ROLE_NOT_FOUND", "Role not found: "+name)
+			return
+		}
+	}
+
+	middleware.AuditCommand(c, fmt.Sprintf("SET ROLES %s %s", user.Email, strings.Join(req.Roles, ",")))
+	err := config.GetDB().WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		for _, role := range roles {
+			if err := tx.Create(&models.UserRole{UserID: user.ID, RoleID: role.ID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	result, err := userRoles(c, user.ID)
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCurrentPermissions 获取当前用户的权限
+func GetCurrentPermissions(c *gin.Context) {
+	claims, _ := middleware.CurrentUser(c)
+	grants, err := middleware.UserGrants(c, claims.UserID())
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+// bindRoleRequest 解析角色请求并检查权限名
+func bindRoleRequest(c *gin.Context, req *models.RoleRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		userError(c, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return false
+	}
+
+	for _, p := range req.Permissions {
+		if !middleware.ValidPermission(p.Permission) {
+			userError(c, http.StatusBadRequest, "UNKNOWN_PERMISSION", "Unknown permission: "+p.Permission)
+			return false
+		}
+	}
+
+	body, _ := json.Marshal(req)
+	middleware.AuditCommand(c, string(body))
+	return true
+}
+
+// findRole 按路径参数 id 查找角色，找不到时直接写入响应
+func findRole(c *gin.Context) (*models.Role, bool) {
+	// 先解析为整数，gorm 会把非数字的内联条件当作 SQL 拼接
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		userError(c, http.StatusBadRequest, "INVALID_ID", "Invalid role id")
+		return nil, false
+	}
+	var role models.Role
+	err = config.GetDB().WithContext(c).Preload("Permissions").First(&role, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		userError(c, http.StatusNotFound, "ROLE_NOT_FOUND", "Role not found")
+		return nil, false
+	}
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return nil, false
+	}
+	return &role, true
+}
+
+func userRoles(ctx context.Context, userID uint) ([]models.Role, error) {
+	var roles []models.Role
+	err := config.GetDB().WithContext(ctx).Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).Error
+	return roles, err
+}
+
+func saveRoleError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		userError(c, http.StatusConflict, "ROLE_NAME_TAKEN", "Role name already in use")
+		return
+	}
+	userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+}
@@ -3,18 +3,26 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
 	"github.com/wgcoder2024/go-web/backend/models"
 )
 
-// GetTables 获取所有表信息
+// GetTables 获取所有表信息，应用自身的表只对管理员显示
 func GetTables(c *gin.Context) {
+	admin, err := middleware.IsAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	var tables []models.TableInfo
-	db := config.DB
+	db := config.GetDB().WithContext(c)
 
 	// 获取所有表名
 	rows, err := db.Raw(`
@@ -41,6 +49,9 @@ func GetTables(c *gin.Context) {
 	for rows.Next() {
 		var table models.TableInfo
 		rows.Scan(&table.Name, &table.ColumnCount, &table.Rows, &table.CreateTime)
+		if !admin && config.IsAppTable(table.Name) {
+			continue
+		}
 		tables = append(tables, table)
 	}
 
@@ -54,7 +65,7 @@ func GetTableDetails(c *gin.Context) {
 	tableInfo.Name = tableName
 
 	// 获取列信息
-	rows, err := config.DB.Raw(`
+	rows, err := config.GetDB().WithContext(c).Raw(`
 		SELECT 
 			column_name,
 			column_type,
@@ -88,7 +99,9 @@ func GetTableDetails(c *gin.Context) {
 func DeleteTable(c *gin.Context) {
 	tableName := c.Param("name")
 
-	if err := config.DB.Exec("DROP TABLE IF EXISTS " + tableName).Error; err != nil {
+	sql := "DROP TABLE IF EXISTS " + quoteIdent(tableName)
+	middleware.AuditCommand(c, sql)
+	if err := config.GetDB().WithContext(c).Exec(sql).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -114,9 +127,9 @@ func CreateTable(c *gin.Context) {
 	}
 
 	// 构建 CREATE TABLE 语句
-	sql := "CREATE TABLE " + req.Name + " (\n"
+	sql := "CREATE TABLE " + quoteIdent(req.Name) + " (\n"
 	for i, col := range req.Columns {
-		sql += col.Name + " " + col.Type
+		sql += quoteIdent(col.Name) + " " + col.Type
 		if !col.Nullable {
 			sql += " NOT NULL"
 		}
@@ -129,7 +142,8 @@ func CreateTable(c *gin.Context) {
 	}
 	sql += "\n)"
 
-	if err := config.DB.Exec(sql).Error; err != nil {
+	middleware.AuditCommand(c, sql)
+	if err := config.GetDB().WithContext(c).Exec(sql).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -160,16 +174,17 @@ func AlterTable(c *gin.Context) {
 	switch req.Action {
 	case "add":
 		sql = fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
-			tableName, req.Column.Name, req.Column.Type)
+			quoteIdent(tableName), quoteIdent(req.Column.Name), req.Column.Type)
 	case "modify":
 		sql = fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s",
-			tableName, req.Column.Name, req.Column.Type)
+			quoteIdent(tableName), quoteIdent(req.Column.Name), req.Column.Type)
 	case "drop":
 		sql = fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
-			tableName, req.Column.Name)
+			quoteIdent(tableName), quoteIdent(req.Column.Name))
 	}
 
-	if err := config.DB.Exec(sql).Error; err != nil {
+	middleware.AuditCommand(c, sql)
+	if err := config.GetDB().WithContext(c).Exec(sql).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -187,13 +202,13 @@ func GetTableData(c *gin.Context) {
 
 	// 获取总记录数
 	var total int64
-	if err := config.DB.Table(tableName).Count(&total).Error; err != nil {
+	if err := config.GetDB().WithContext(c).Table(tableName).Count(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// 获取列信息
-	columns, err := config.DB.Raw(`
+	columns, err := config.GetDB().WithContext(c).Raw(`
 		SELECT column_name 
 		FROM information_schema.columns 
 		WHERE table_schema = DATABASE() 
@@ -213,14 +228,32 @@ func GetTableData(c *gin.Context) {
 		columnNames = append(columnNames, name)
 	}
 
-	// 构建查询
+	// 构建查询，排序字段必须是表中的列，不存在时不排序
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if strings.EqualFold(sortOrder, "desc") {
+		sortOrder = "DESC"
+	} else {
+		sortOrder = "ASC"
+	}
+	orderBy := ""
+	for _, name := range columnNames {
+		if name == sortField {
+			orderBy = fmt.Sprintf(" ORDER BY %s %s", quoteIdent(name), sortOrder)
+			break
+		}
+	}
 	offset := (page - 1) * pageSize
 	query := fmt.Sprintf(
-		"SELECT * FROM %s ORDER BY %s %s LIMIT %d OFFSET %d",
-		tableName, sortField, sortOrder, pageSize, offset,
+		"SELECT * FROM %s%s LIMIT %d OFFSET %d",
+		quoteIdent(tableName), orderBy, pageSize, offset,
 	)
 
-	rows, err := config.DB.Raw(query).Rows()
+	rows, err := config.GetDB().WithContext(c).Raw(query).Rows()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -258,8 +291,11 @@ func ExecuteSQL(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "只允许执行 SELECT 语句"})
 		return
 	}
+	if !checkAppTables(c, query.SQL) {
+		return
+	}
 
-	rows, err := config.DB.Raw(query.SQL).Rows()
+	rows, err := config.GetDB().WithContext(c).Raw(query.SQL).Rows()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -293,14 +329,14 @@ func ExportTable(c *gin.Context) {
 
 	// 获取表结构
 	var createSQL string
-	err := config.DB.Raw(`SHOW CREATE TABLE `+tableName).Row().Scan(&tableName, &createSQL)
+	err := config.GetDB().WithContext(c).Raw("SHOW CREATE TABLE "+quoteIdent(tableName)).Row().Scan(&tableName, &createSQL)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// 获取表数据
-	rows, err := config.DB.Raw("SELECT * FROM " + tableName).Rows()
+	rows, err := config.GetDB().WithContext(c).Raw("SELECT * FROM " + quoteIdent(tableName)).Rows()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -318,7 +354,7 @@ func ExportTable(c *gin.Context) {
 		rows.Scan(valuePtrs...)
 
 		// 构建 INSERT 语句
-		data = append(data, fmt.Sprintf("INSERT INTO %s VALUES (...);", tableName))
+		data = append(data, fmt.Sprintf("INSERT INTO %s VALUES (...);", quoteIdent(tableName)))
 	}
 
 	backup := models.TableBackup{
@@ -338,7 +374,13 @@ func ImportTable(c *gin.Context) {
 		return
 	}
 
-	tx := config.DB.Begin()
+	if !checkAppTables(c, backup.Name, backup.Structure, backup.Data) {
+		return
+	}
+
+	middleware.AuditCommand(c, backup.Structure)
+	middleware.AuditCommand(c, backup.Data)
+	tx := config.GetDB().WithContext(c).Begin()
 
 	// 创建表
 	if err := tx.Exec(backup.Structure).Error; err != nil {
@@ -359,3 +401,33 @@ func ImportTable(c *gin.Context) {
 	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{"message": "表导入成功"})
 }
+
+// sqlWordPattern SQL 中可能是表名的单词，字符串和注释中的单词也会匹配
+var sqlWordPattern = regexp.MustCompile(`[A-Za-z0-9_$]+`)
+
+// checkAppTables 非管理员提交的 SQL 引用了应用自身的表时返回 403
+// 按单词匹配，列名或字符串与这些表同名时也会被拒绝
+func checkAppTables(c *gin.Context, sqls ...string) bool {
+	admin, err := middleware.IsAdmin(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if admin {
+		return true
+	}
+	for _, sql := range sqls {
+		for _, word := range sqlWordPattern.FindAllString(sql, -1) {
+			if config.IsAppTable(word) {
+				c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("表 %s 属于应用自身，只有管理员可以访问", word)})
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// quoteIdent 用反引号引用表名或列名
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -1,40 +1,263 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
 	"github.com/wgcoder2024/go-web/backend/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// userSortFields 允许排序和游标分页的字段（JSON 字段名 -> 列名）
+var userSortFields = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"age":        "age",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// userCursor 游标内容：上一页最后一条记录的排序字段值和 ID
+type userCursor struct {
+	ID    uint            `json:"id"`
+	Value json.RawMessage `json:"v"`
+}
+
 // GetUsers 获取用户列表
+// 支持 page/pageSize 分页或 cursor 游标分页，sortField/sortOrder 排序，
+// 以及 name、email、minAge/maxAge、createdFrom/createdTo、updatedFrom/updatedTo 过滤，
+// deleted=include 时包含已删除用户，deleted=only 时只返回已删除用户
 func GetUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	sortField := c.DefaultQuery("sortField", "id")
+	sortOrder := strings.ToLower(c.DefaultQuery("sortOrder", "asc"))
+	cursor := c.Query("cursor")
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+	column, ok := userSortFields[sortField]
+	if !ok {
+		userError(c, http.StatusBadRequest, "INVALID_QUERY", "不支持按 "+sortField+" 排序")
+		return
+	}
+	if sortOrder != "asc" && sortOrder != "desc" {
+		userError(c, http.StatusBadRequest, "INVALID_QUERY", "sortOrder 只能是 asc 或 desc")
+		return
+	}
+
+	query, err := filterUsers(c, config.GetDB().WithContext(c).Model(&models.User{}))
+	if err != nil {
+		userError(c, http.StatusBadRequest, "INVALID_QUERY", err.Error())
+		return
+	}
+
+	// 新会话，避免 Count 修改后续查询的语句
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	result := models.UserPage{Total: total, PageSize: pageSize}
+	result.TotalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	if cursor != "" {
+		query, err = applyUserCursor(query, cursor, sortField, column, sortOrder)
+		if err != nil {
+			userError(c, http.StatusBadRequest, "INVALID_QUERY", err.Error())
+			return
+		}
+	} else {
+		result.Page = page
+		query = query.Offset((page - 1) * pageSize)
+	}
+
+	// 多取一条用于判断是否还有下一页，id 作为次排序保证顺序稳定
+	order := column + " " + sortOrder
+	if column != "id" {
+		order += ", id " + sortOrder
+	}
 	var users []models.User
-	name := c.Query("name") // 搜索参数
+	if err := query.Order(order).Limit(pageSize + 1).Find(&users).Error; err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		result.HasMore = true
+		result.NextCursor = encodeUserCursor(users[len(users)-1], sortField)
+	}
+	result.Items = users
+	if result.Items == nil {
+		result.Items = []models.User{}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// filterUsers 根据查询参数添加过滤条件
+func filterUsers(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	switch c.Query("deleted") {
+	case "":
+	case "include":
+		query = query.Unscoped()
+	case "only":
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	default:
+		return nil, errors.New("deleted 只能是 include 或 only")
+	}
 
-	query := config.DB
-	if name != "" {
+	if name := c.Query("name"); name != "" {
 		query = query.Where("name LIKE ?", "%"+name+"%")
 	}
+	if email := c.Query("email"); email != "" {
+		query = query.Where("email LIKE ?", "%"+email+"%")
+	}
 
-	if err := query.Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	for param, cond := range map[string]string{"minAge": "age >= ?", "maxAge": "age <= ?"} {
+		if v := c.Query(param); v != "" {
+			age, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s 必须是整数", param)
+			}
+			query = query.Where(cond, age)
+		}
+	}
+
+	ranges := []struct{ from, to, column string }{
+		{"createdFrom", "createdTo", "created_at"},
+		{"updatedFrom", "updatedTo", "updated_at"},
 	}
+	for _, r := range ranges {
+		if v := c.Query(r.from); v != "" {
+			t, _, err := parseUserTime(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s 格式错误: %v", r.from, err)
+			}
+			query = query.Where(r.column+" >= ?", t)
+		}
+		if v := c.Query(r.to); v != "" {
+			t, dateOnly, err := parseUserTime(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s 格式错误: %v", r.to, err)
+			}
+			if dateOnly {
+				// 只有日期时包含当天
+				query = query.Where(r.column+" < ?", t.AddDate(0, 0, 1))
+			} else {
+				query = query.Where(r.column+" <= ?", t)
+			}
+		}
+	}
+	return query, nil
+}
 
-	c.JSON(http.StatusOK, users)
+// parseUserTime 解析 RFC3339 时间或 2006-01-02 格式的日期
+func parseUserTime(v string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	return t, false, err
+}
+
+// applyUserCursor 按游标添加 keyset 条件
+func applyUserCursor(query *gorm.DB, cursor, sortField, column, sortOrder string) (*gorm.DB, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("cursor 无效")
+	}
+	var cur userCursor
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return nil, errors.New("cursor 无效")
+	}
+
+	op := ">"
+	if sortOrder == "desc" {
+		op = "<"
+	}
+	if column == "id" {
+		return query.Where("id "+op+" ?", cur.ID), nil
+	}
+
+	var value interface{}
+	switch sortField {
+	case "age":
+		var v int
+		err = json.Unmarshal(cur.Value, &v)
+		value = v
+	case "created_at", "updated_at":
+		var v time.Time
+		err = json.Unmarshal(cur.Value, &v)
+		value = v
+	default:
+		var v string
+		err = json.Unmarshal(cur.Value, &v)
+		value = v
+	}
+	if err != nil {
+		return nil, errors.New("cursor 与排序字段不匹配")
+	}
+
+	return query.Where(
+		fmt.Sprintf("(%s %s ?) OR (%s = ? AND id %s ?)", column, op, column, op),
+		value, value, cur.ID,
+	), nil
+}
+
+func encodeUserCursor(user models.User, sortField string) string {
+	var value interface{}
+	switch sortField {
+	case "name":
+		value = user.Name
+	case "email":
+		value = user.Email
+	case "age":
+		value = user.Age
+	case "created_at":
+		value = user.CreatedAt
+	case "updated_at":
+		value = user.UpdatedAt
+	}
+
+	raw, _ := json.Marshal(value)
+	data, _ := json.Marshal(userCursor{ID: user.ID, Value: raw})
+	return base64.RawURLEncoding.EncodeToString(data)
 }
 
 // GetUser 获取单个用户
 func GetUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
 	var user models.User
-	id := c.Param("id")
 
-	if err := config.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	if err := config.GetDB().WithContext(c).First(&user, id).Error; err != nil {
+		userError(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
 		return
 	}
 
@@ -43,46 +266,311 @@ func GetUser(c *gin.Context) {
 
 // CreateUser 创建用户
 func CreateUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req models.UserCreate
+	if !bindUserRequest(c, &req) {
 		return
 	}
 
-	if err := config.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !checkUserEmail(c, req.Email, 0) {
+		return
+	}
+
+	user := models.User{Name: req.Name, Email: req.Email, Age: req.Age}
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+		user.PasswordHash = string(hash)
+	}
+	err := config.GetDB().WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return recordUserAudit(tx, c, "create", user.ID, nil, &user)
+	})
+	if err != nil {
+		saveUserError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, user)
 }
 
-// UpdateUser 更新用户
+// UpdateUser 更新用户，PUT 和 PATCH 均为部分更新，只修改请求中出现的字段
 func UpdateUser(c *gin.Context) {
-	id, _ := strconv.Atoi(c.Param("id"))
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
 	var user models.User
 
-	if err := config.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	if err := config.GetDB().WithContext(c).First(&user, id).Error; err != nil {
+		userError(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
 		return
 	}
 
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req models.UserUpdate
+	if !bindUserRequest(c, &req) {
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Email != nil && *req.Email != user.Email {
+		if !checkUserEmail(c, *req.Email, user.ID) {
+			return
+		}
+		updates["email"] = *req.Email
+	}
+	if req.Age != nil {
+		updates["age"] = *req.Age
+	}
+	if req.Password != nil {
+		// 只有管理员可以修改管理员的密码，否则拥有 users.write 即可接管管理员账号
+		targetAdmin, err := middleware.UserIsAdmin(c, user.ID)
+		if err != nil {
+			userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+		if targetAdmin {
+			admin, err := middleware.IsAdmin(c)
+			if err != nil {
+				userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+				return
+			}
+			if !admin {
+				userError(c, http.StatusForbidden, "FORBIDDEN", "Only administrators can change an administrator's password")
+				return
+			}
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+		updates["password_hash"] = string(hash)
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusOK, user)
+		return
+	}
+
+	before := user
+	err := config.GetDB().WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		// 修改密码后已签发的刷新令牌全部失效
+		if req.Password != nil {
+			if err := revokeUserTokens(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		if err := tx.First(&user, user.ID).Error; err != nil {
+			return err
+		}
+		return recordUserAudit(tx, c, "update", user.ID, &before, &user)
+	})
+	if err != nil {
+		saveUserError(c, err)
 		return
 	}
 
-	config.DB.Save(&user)
 	c.JSON(http.StatusOK, user)
 }
 
-// DeleteUser 删除用户
+// DeleteUser 删除用户（软删除）
 func DeleteUser(c *gin.Context) {
-	id := c.Param("id")
-	if err := config.DB.Delete(&models.User{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	var user models.User
+
+	if err := config.GetDB().WithContext(c).First(&user, id).Error; err != nil {
+		userError(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+		return
+	}
+
+	err := config.GetDB().WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		if err := revokeUserTokens(tx, user.ID); err != nil {
+			return err
+		}
+		return recordUserAudit(tx, c, "delete", user.ID, &user, nil)
+	})
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
+
+// RestoreUser 恢复已删除的用户
+func RestoreUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	var user models.User
+
+	if err := config.GetDB().WithContext(c).Unscoped().First(&user, id).Error; err != nil {
+		userError(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+		return
+	}
+	if !user.DeletedAt.Valid {
+		userError(c, http.StatusConflict, "USER_NOT_DELETED", "User is not deleted")
+		return
+	}
+
+	before := user
+	err := config.GetDB().WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&user).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&user, user.ID).Error; err != nil {
+			return err
+		}
+		return recordUserAudit(tx, c, "restore", user.ID, &before, &user)
+	})
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// userIDParam 解析路径中的用户 ID，不是正整数时返回 400
+// 不能把原始字符串直接传给 First：gorm 会把非数字的内联条件当作 SQL 拼接
+func userIDParam(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		userError(c, http.StatusBadRequest, "INVALID_ID", "Invalid user id")
+		return 0, false
+	}
+	return id, true
+}
+
+// userError 返回带错误码的错误响应
+func userError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, models.ErrorResponse{Error: message, Code: code})
+}
+
+// bindUserRequest 解析请求体，去除首尾空白后校验，失败时返回字段级错误
+func bindUserRequest(c *gin.Context, req interface{}) bool {
+	if err := json.NewDecoder(c.Request.Body).Decode(req); err != nil {
+		userError(c, http.StatusBadRequest, "INVALID_JSON", "Invalid request body: "+err.Error())
+		return false
+	}
+
+	switch r := req.(type) {
+	case *models.UserCreate:
+		r.Name = strings.TrimSpace(r.Name)
+		r.Email = strings.ToLower(strings.TrimSpace(r.Email))
+	case *models.UserUpdate:
+		if r.Name != nil {
+			*r.Name = strings.TrimSpace(*r.Name)
+		}
+		if r.Email != nil {
+			*r.Email = strings.ToLower(strings.TrimSpace(*r.Email))
+		}
+	}
+
+	err := binding.Validator.ValidateStruct(req)
+	if err == nil {
+		return true
+	}
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		userError(c, http.StatusBadRequest, "VALIDATION_FAILED", err.Error())
+		return false
+	}
+
+	resp := models.ErrorResponse{Error: "Validation failed", Code: "VALIDATION_FAILED"}
+	t := reflect.TypeOf(req).Elem()
+	for _, fe := range errs {
+		field := fe.Field()
+		if sf, ok := t.FieldByName(fe.StructField()); ok {
+			if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" {
+				field = name
+			}
+		}
+		code, message := userFieldError(fe)
+		resp.Fields = append(resp.Fields, models.FieldError{Field: field, Code: code, Message: message})
+	}
+	c.JSON(http.StatusBadRequest, resp)
+	return false
+}
+
+// userFieldError 将校验规则转换为错误码和提示
+func userFieldError(fe validator.FieldError) (string, string) {
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required":
+		return "REQUIRED", "is required"
+	case "email":
+		return "INVALID_EMAIL", "must be a valid email address"
+	case "max":
+		if isString {
+			return "TOO_LONG", "must be at most " + fe.Param() + " characters"
+		}
+		return "OUT_OF_RANGE", "must be at most " + fe.Param()
+	case "min":
+		if isString && fe.Param() == "1" {
+			return "TOO_SHORT", "must not be empty"
+		}
+		if isString {
+			return "TOO_SHORT", "must be at least " + fe.Param() + " characters"
+		}
+		return "OUT_OF_RANGE", "must be at least " + fe.Param()
+	case "gte":
+		return "OUT_OF_RANGE", "must be greater than or equal to " + fe.Param()
+	case "lte":
+		return "OUT_OF_RANGE", "must be less than or equal to " + fe.Param()
+	default:
+		return "INVALID", "failed on the '" + fe.Tag() + "' rule"
+	}
+}
+
+// checkUserEmail 检查邮箱是否已被其他用户使用，已占用时返回 409
+// 唯一索引同样约束已删除的用户，此时需要先恢复原用户
+func checkUserEmail(c *gin.Context, email string, excludeID uint) bool {
+	var count int64
+	err := config.GetDB().WithContext(c).Unscoped().Model(&models.User{}).Where("email = ? AND id <> ?", email, excludeID).Count(&count).Error
+	if err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return false
+	}
+	if count > 0 {
+		emailTaken(c)
+		return false
+	}
+	return true
+}
+
+// saveUserError 处理写入错误，并发写入时唯一索引冲突同样返回 409
+func saveUserError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		emailTaken(c)
+		return
+	}
+	userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+}
+
+func emailTaken(c *gin.Context) {
+	c.JSON(http.StatusConflict, models.ErrorResponse{
+		Error:  "Email already in use",
+		Code:   "EMAIL_TAKEN",
+		Fields: []models.FieldError{{Field: "email", Code: "EMAIL_TAKEN", Message: "is already in use"}},
+	})
+}
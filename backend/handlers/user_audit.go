@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetUserAudit 获取用户变更记录，按时间倒序
+func GetUserAudit(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	var user models.User
+
+	if err := config.GetDB().WithContext(c).Unscoped().First(&user, id).Error; err != nil {
+		userError(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+		return
+	}
+
+	var audits []models.UserAudit
+	if err := config.GetDB().WithContext(c).Where("user_id = ?", user.ID).Order("id desc").Find(&audits).Error; err != nil {
+		userError(c, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, audits)
+}
+
+// requestActor 当前操作人，即登录用户的邮箱
+func requestActor(c *gin.Context) string {
+	if claims, ok := middleware.CurrentUser(c); ok {
+		return claims.Email
+	}
+	return "anonymous"
+}
+
+// recordUserAudit 在同一事务中写入变更记录，before/after 为 nil 时记录为 NULL
+func recordUserAudit(tx *gorm.DB, c *gin.Context, action string, userID uint, before, after *models.User) error {
+	audit := models.UserAudit{
+		UserID: userID,
+		Action: action,
+		Actor:  requestActor(c),
+	}
+
+	var err error
+	if before != nil {
+		if audit.Before, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if audit.After, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+	return tx.Create(&audit).Error
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormLogger 将 gorm 日志写入 slog：SQL 错误和慢查询记为警告，debug 级别下记录所有 SQL
+// SQL 中的参数不展开，避免密码哈希、令牌等值进入日志
+type GormLogger struct{}
+
+func (l GormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	slog.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	slog.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	slog.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := isSlow(elapsed)
+	if !failed && !slow && !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("elapsed_ms", durationMillis(elapsed)),
+	}
+	switch {
+	case failed:
+		slog.LogAttrs(ctx, slog.LevelWarn, "sql error", append(attrs, slog.String("error", err.Error()))...)
+	case slow:
+		slog.LogAttrs(ctx, slog.LevelWarn, "slow sql", attrs...)
+	default:
+		slog.LogAttrs(ctx, slog.LevelDebug, "sql", attrs...)
+	}
+}
+
+// ParamsFilter 日志中保留 ? 占位符，不代入参数
+func (GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	level         = new(slog.LevelVar)
+	slowThreshold atomic.Int64
+)
+
+// Setup 初始化全局日志：format 为 json（默认）或 text，level 为 debug、info、warn、error
+// 同时接管标准库 log、gin 路由调试信息和 go-redis 内部日志
+func Setup(format, lvl string, slow time.Duration) error {
+	if err := SetLevel(lvl); err != nil {
+		return err
+	}
+	SetSlowThreshold(slow)
+
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	switch format {
+	case "", "json":
+		h = slog.NewJSONHandler(os.Stdout, opts)
+	case "text":
+		h = slog.NewTextHandler(os.Stdout, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+	slog.SetDefault(slog.New(contextHandler{h}))
+
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		slog.Debug("route registered", "method", method, "path", path, "handler", handler)
+	}
+	redis.SetLogger(redisLogger{})
+	return nil
+}
+
+// SetLevel 修改日志级别，配置重载时调用
+func SetLevel(lvl string) error {
+	if lvl == "" {
+		lvl = "info"
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(lvl)); err != nil {
+		return fmt.Errorf("unknown log level %q", lvl)
+	}
+	level.Set(l)
+	return nil
+}
+
+// SetSlowThreshold 修改慢查询阈值，SQL 和 Redis 命令超过阈值时记录警告，为 0 时不记录
+func SetSlowThreshold(d time.Duration) {
+	slowThreshold.Store(int64(d))
+}
+
+func isSlow(elapsed time.Duration) bool {
+	threshold := time.Duration(slowThreshold.Load())
+	return threshold > 0 && elapsed >= threshold
+}
+
+// contextHandler 从 context 中取出请求 ID 和追踪 ID 附加到每条日志
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if ctx != nil {
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
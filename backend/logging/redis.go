@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisLogger go-redis 内部日志（如连接池、哨兵切换）
+type redisLogger struct{}
+
+func (redisLogger) Printf(ctx context.Context, format string, v ...interface{}) {
+	slog.WarnContext(ctx, fmt.Sprintf(format, v...), "component", "redis")
+}
+
+// RedisHook 记录超过慢查询阈值的 Redis 命令，debug 级别下记录命令错误
+// 只记录命令名和键，不记录值
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		logRedis(ctx, cmd.Name(), redisKey(cmd), time.Since(start), err)
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+		logRedis(ctx, "pipeline", strings.Join(names, ","), time.Since(start), err)
+		return err
+	}
+}
+
+func logRedis(ctx context.Context, command, key string, elapsed time.Duration, err error) {
+	attrs := []slog.Attr{
+		slog.String("command", command),
+		slog.String("key", key),
+		slog.Float64("elapsed_ms", durationMillis(elapsed)),
+	}
+	switch {
+	case isSlow(elapsed):
+		if err != nil && !errors.Is(err, redis.Nil) {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		slog.LogAttrs(ctx, slog.LevelWarn, "slow redis command", attrs...)
+	case err != nil && !errors.Is(err, redis.Nil):
+		slog.LogAttrs(ctx, slog.LevelDebug, "redis error", append(attrs, slog.String("error", err.Error()))...)
+	}
+}
+
+// redisSensitiveCommands 参数中可能含有密码的命令（AUTH、HELLO ... AUTH、ACL SETUSER、CONFIG SET requirepass、MIGRATE ... AUTH），不记录参数
+var redisSensitiveCommands = map[string]bool{
+	"auth":    true,
+	"hello":   true,
+	"acl":     true,
+	"config":  true,
+	"migrate": true,
+}
+
+// redisKey 命令的第一个参数，多数命令中是键名；过长时截断
+func redisKey(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return ""
+	}
+	if redisSensitiveCommands[strings.ToLower(cmd.Name())] {
+		return "[redacted]"
+	}
+	key := fmt.Sprint(args[1])
+	if len(key) > 200 {
+		key = key[:200] + "..."
+	}
+	return key
+}
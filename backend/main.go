@@ -1,69 +1,401 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/wgcoder2024/go-web/backend/config"
 	"github.com/wgcoder2024/go-web/backend/handlers"
+	"github.com/wgcoder2024/go-web/backend/logging"
+	"github.com/wgcoder2024/go-web/backend/metrics"
+	"github.com/wgcoder2024/go-web/backend/middleware"
+	"github.com/wgcoder2024/go-web/backend/tracing"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	cfg := config.GetConfig()
+	if len(os.Args) > 1 && os.Args[1] == "secret" {
+		os.Exit(runSecretCommand(os.Args[2:]))
+	}
+
+	configPath := flag.String("config", "", "配置文件路径，默认读取 GO_WEB_CONFIG 或 config/config.yaml")
+	env := flag.String("env", "", "环境名，会加载同目录下的 config.<env>.yaml 覆盖基础配置，默认读取 GO_WEB_ENV")
+	flag.Parse()
+	config.SetPath(*configPath, *env)
+
+	// 加载配置并开始监听配置文件变化
+	config.GetConfig()
+
+	// 初始化日志，日志级别和慢查询阈值支持热更新
+	lc := config.Log()
+	if err := logging.Setup(lc.Format, lc.Level, lc.SlowQueryThreshold); err != nil {
+		slog.Error("Failed to set up logging", "error", err)
+		os.Exit(1)
+	}
+	config.OnReload("logging", func(old, new *config.Config) error {
+		if new.Log.Format != old.Log.Format {
+			return errors.New("log format changed, restart required")
+		}
+		logging.SetSlowThreshold(new.Log.SlowQueryThreshold)
+		return logging.SetLevel(new.Log.Level)
+	})
+
+	// 初始化追踪，需要在创建数据库和 Redis 连接之前完成
+	tc := config.Tracing()
+	if err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    tc.Exporter,
+		Protocol:    tc.Protocol,
+		Endpoint:    tc.Endpoint,
+		Insecure:    tc.Insecure,
+		SampleRatio: tc.SampleRatio,
+		ServiceName: tc.ServiceName,
+	}); err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	config.OnReload("tracing", func(old, new *config.Config) error {
+		if !reflect.DeepEqual(old.Tracing, new.Tracing) {
+			return errors.New("tracing settings changed, restart required")
+		}
+		return nil
+	})
+
+	// 监听端口和超时无法热更新，在重载状态中提示需要重启
+	config.OnReload("server", func(old, new *config.Config) error {
+		prev, next := old.Server, new.Server
+		prev.Cors, next.Cors = config.CorsConfig{}, config.CorsConfig{}
+		prev.ShutdownTimeout, next.ShutdownTimeout = 0, 0
+		if !reflect.DeepEqual(prev, next) {
+			return errors.New("server settings changed, restart required")
+		}
+		return nil
+	})
 
 	// 初始化数据库
 	config.InitDB()
 
+	// 初始化 Redis，失败时关闭已打开的数据库连接并导出已有的 span 后退出
+	if err := config.InitRedis(); err != nil {
+		slog.Error("Failed to connect to Redis", "error", err)
+		config.CloseDB()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		tracing.Shutdown(ctx)
+		cancel()
+		os.Exit(1)
+	}
+	// 重载替换 Redis 客户端后关闭旧客户端上的订阅会话，通知前端重新连接
+	config.OnReload("redis-pubsub", func(old, new *config.Config) error {
+		handlers.CloseStaleRedisPubSubSessions()
+		return nil
+	})
+
 	// 创建 Gin 路由
-	r := gin.Default()
-
-	// CORS 配置
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     cfg.Server.Cors.AllowedOrigins,
-		AllowMethods:     cfg.Server.Cors.AllowedMethods,
-		AllowHeaders:     cfg.Server.Cors.AllowedHeaders,
-		AllowCredentials: true,
-	}))
-
-	// API 路由
-	v1 := r.Group("/api/v1")
+	r := gin.New()
+	// 处理函数中以 gin.Context 作为 context 传给 gorm 时，能读到请求 context 中的值
+	r.ContextWithFallback = true
+
+	// 请求 ID 与访问日志，放在最前面以便后续日志都带上请求 ID
+	r.Use(logging.Middleware())
+
+	// 请求追踪，SQL 和 Redis 的 span 挂在请求 span 下
+	r.Use(tracing.Middleware())
+
+	// 请求指标，放在 Recovery 之前以统计 panic 返回的 500
+	metrics.Registry.MustRegister(metrics.NewPoolCollector(config.GetDB, config.GetRDB))
+	r.Use(metrics.Middleware())
+	r.Use(logging.Recovery())
+
+	// CORS 配置，随配置文件热重载
+	r.Use(middleware.CORS())
+
+	// 存活和就绪检查，无需登录，供 Kubernetes 探针使用
+	r.GET("/healthz", handlers.Healthz)
+	r.GET("/readyz", handlers.Readyz)
+
+	// Prometheus 指标
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// 登录认证，无需令牌
+	auth := r.Group("/api/v1/auth")
 	{
+		auth.POST("/login", handlers.Login)
+		auth.POST("/refresh", handlers.RefreshToken)
+		auth.POST("/logout", handlers.Logout)
+	}
+
+	// API 路由，需要登录
+	v1 := r.Group("/api/v1", middleware.Auth())
+	{
+		v1.GET("/auth/me", handlers.GetCurrentUser)
+		v1.GET("/auth/permissions", handlers.GetCurrentPermissions)
+
+		// 审计日志
+		audit := v1.Group("/audit", middleware.Require("audit.read", nil))
+		{
+			audit.GET("", handlers.GetAuditLogs)
+			audit.GET("/export", handlers.ExportAuditLogs)
+		}
+
+		// 配置热重载状态
+		v1.GET("/config/status", middleware.Require("config.read", nil), handlers.GetConfigStatus)
+
+		// 角色与授权
+		roles := v1.Group("/roles")
+		{
+			roles.GET("", middleware.Require("roles.manage", nil), handlers.GetRoles)
+			roles.GET("/permissions", middleware.Require("roles.manage", nil), handlers.GetPermissions)
+			roles.POST("", middleware.Audit("role.create", middleware.BodyField("name")), middleware.Require("roles.manage", nil), handlers.CreateRole)
+			roles.PUT("/:id", middleware.Audit("role.update", middleware.Param("id")), middleware.Require("roles.manage", nil), handlers.UpdateRole)
+			roles.DELETE("/:id", middleware.Audit("role.delete", middleware.Param("id")), middleware.Require("roles.manage", nil), handlers.DeleteRole)
+		}
+
 		users := v1.Group("/users")
 		{
-			users.GET("", handlers.GetUsers)
-			users.GET("/:id", handlers.GetUser)
-			users.POST("", handlers.CreateUser)
-			users.PUT("/:id", handlers.UpdateUser)
-			users.DELETE("/:id", handlers.DeleteUser)
+			users.GET("", middleware.Require("users.read", nil), handlers.GetUsers)
+			users.GET("/:id", middleware.Require("users.read", nil), handlers.GetUser)
+			users.POST("", middleware.Require("users.write", nil), handlers.CreateUser)
+			users.PUT("/:id", middleware.Require("users.write", nil), handlers.UpdateUser)
+			users.PATCH("/:id", middleware.Require("users.write", nil), handlers.UpdateUser)
+			users.DELETE("/:id", middleware.Require("users.write", nil), handlers.DeleteUser)
+			users.POST("/:id/restore", middleware.Require("users.write", nil), handlers.RestoreUser)
+			users.GET("/:id/audit", middleware.Require("users.read", nil), handlers.GetUserAudit)
+			users.GET("/:id/roles", middleware.Require("roles.manage", nil), handlers.GetUserRoles)
+			users.PUT("/:id/roles", middleware.Audit("role.assign", middleware.Param("id")), middleware.Require("roles.manage", nil), handlers.SetUserRoles)
 		}
 
 		// 数据库表管理路由
 		tables := v1.Group("/tables")
 		{
-			tables.GET("", handlers.GetTables)
-			tables.GET("/:name", handlers.GetTableDetails)
-			tables.POST("", handlers.CreateTable)
-			tables.DELETE("/:name", handlers.DeleteTable)
+			tables.GET("", middleware.Require("tables.read", nil), handlers.GetTables)
+			tables.GET("/:name", middleware.Require("tables.read", middleware.Table("name")), handlers.GetTableDetails)
+			tables.POST("", middleware.Audit("table.create", middleware.BodyField("name")), middleware.Require("tables.ddl", middleware.NewTable("name")), handlers.CreateTable)
+			tables.DELETE("/:name", middleware.Audit("table.delete", middleware.Param("name")), middleware.Require("tables.ddl", middleware.Table("name")), handlers.DeleteTable)
 
 			// 新增路由
-			tables.PUT("/:name", handlers.AlterTable)
-			tables.GET("/:name/data", handlers.GetTableData)
-			tables.POST("/query", handlers.ExecuteSQL)
-			tables.GET("/:name/export", handlers.ExportTable)
-			tables.POST("/import", handlers.ImportTable)
+			tables.PUT("/:name", middleware.Audit("table.alter", middleware.Param("name")), middleware.Require("tables.ddl", middleware.Table("name")), handlers.AlterTable)
+			tables.GET("/:name/data", middleware.Require("tables.read", middleware.Table("name")), handlers.GetTableData)
+			tables.POST("/query", middleware.Require("tables.read", middleware.Global), handlers.ExecuteSQL)
+			tables.GET("/:name/export", middleware.Require("tables.read", middleware.Table("name")), handlers.ExportTable)
+			tables.POST("/import", middleware.Audit("table.import", middleware.BodyField("name")), middleware.Require("tables.ddl", middleware.Global), handlers.ImportTable)
 		}
 
 		redis := v1.Group("/redis")
 		{
-			redis.GET("/keys", handlers.GetRedisKeys)
-			redis.POST("/keys", handlers.SetRedisKey)
-			redis.DELETE("/keys/:key", handlers.DeleteRedisKey)
+			redis.GET("/keys", middleware.Require("redis.read", middleware.KeyPattern(middleware.Query("pattern"))), handlers.GetRedisKeys)
+			redis.POST("/keys", middleware.Audit("redis.set", middleware.BodyField("key")), middleware.Require("redis.write", middleware.BodyField("key")), handlers.SetRedisKey)
+			redis.DELETE("/keys/:key", middleware.Audit("redis.delete", middleware.Param("key")), middleware.Require("redis.delete", middleware.Param("key")), handlers.DeleteRedisKey)
+			redis.POST("/command", middleware.Audit("redis.command", nil), middleware.Require("redis.admin", middleware.Global), handlers.ExecuteRedisCommand)
+
+			// 服务器状态，信息、慢日志、客户端和内存统计涉及所有键，只有不限范围的授权才能查看和管理
+			redis.GET("/info", middleware.Require("redis.read", middleware.Global), handlers.GetRedisInfo)
+			redis.GET("/slowlog", middleware.Require("redis.read", middleware.Global), handlers.GetRedisSlowLog)
+			redis.DELETE("/slowlog", middleware.Audit("redis.slowlog.reset", nil), middleware.Require("redis.admin", middleware.Global), handlers.ResetRedisSlowLog)
+			redis.GET("/clients", middleware.Require("redis.read", middleware.Global), handlers.GetRedisClients)
+			redis.POST("/clients/kill", middleware.Audit("redis.client.kill", nil), middleware.Require("redis.admin", middleware.Global), handlers.KillRedisClient)
+			redis.GET("/memory", middleware.Require("redis.read", middleware.Global), handlers.GetRedisMemoryStats)
+			redis.GET("/dbsize", middleware.Require("redis.read", middleware.Global), handlers.GetRedisDBSize)
+
+			// 大键/热键分析，任务按键模式检查授权范围
+			redis.POST("/analyze", middleware.Require("redis.read", middleware.KeyPattern(middleware.BodyField("pattern"))), handlers.StartRedisAnalyze)
+			redis.GET("/analyze", middleware.Require("redis.read", nil), handlers.GetRedisAnalyzeJobs)
+			redis.GET("/analyze/:id", middleware.Require("redis.read", middleware.KeyPattern(handlers.RedisAnalyzeJobPattern)), handlers.GetRedisAnalyzeJob)
+			redis.DELETE("/analyze/:id", middleware.Require("redis.read", middleware.KeyPattern(handlers.RedisAnalyzeJobPattern)), handlers.DeleteRedisAnalyzeJob)
+
+			// 导入导出
+			redis.GET("/export", middleware.Require("redis.read", middleware.Global), handlers.ExportRedisKeys)
+			redis.POST("/import", middleware.Audit("redis.import", nil), middleware.Require("redis.write", middleware.Global), handlers.ImportRedisKeys)
+
+			// Lua 脚本与函数库，脚本可以访问任意键，函数库加载到所有节点，只有不限范围的授权才能管理
+			redis.GET("/scripts", middleware.Require("redis.read", middleware.Global), handlers.GetRedisScripts)
+			redis.POST("/scripts", middleware.Audit("redis.script.create", middleware.BodyField("name")), middleware.Require("redis.admin", middleware.Global), handlers.CreateRedisScript)
+			redis.GET("/scripts/:name", middleware.Require("redis.read", middleware.Global), handlers.GetRedisScript)
+			redis.PUT("/scripts/:name", middleware.Audit("redis.script.update", middleware.Param("name")), middleware.Require("redis.admin", middleware.Global), handlers.UpdateRedisScript)
+			redis.DELETE("/scripts/:name", middleware.Audit("redis.script.delete", middleware.Param("name")), middleware.Require("redis.admin", middleware.Global), handlers.DeleteRedisScript)
+			redis.POST("/scripts/:name/load", middleware.Audit("redis.script.load", middleware.Param("name")), middleware.Require("redis.admin", middleware.Global), handlers.LoadRedisScript)
+			redis.POST("/scripts/:name/run", middleware.Audit("redis.script.run", middleware.Param("name")), middleware.Require("redis.write", middleware.Global), handlers.RunRedisScript)
+			redis.GET("/functions", middleware.Require("redis.read", middleware.Global), handlers.GetRedisFunctions)
+			redis.POST("/functions", middleware.Audit("redis.function.load", nil), middleware.Require("redis.admin", middleware.Global), handlers.LoadRedisFunction)
+			redis.DELETE("/functions/:library", middleware.Audit("redis.function.delete", middleware.Param("library")), middleware.Require("redis.admin", middleware.Global), handlers.DeleteRedisFunction)
+			redis.POST("/functions/call", middleware.Audit("redis.function.call", middleware.BodyField("function")), middleware.Require("redis.write", middleware.Global), handlers.CallRedisFunction)
+
+			// Stream 与消费者组
+			redis.GET("/streams/:key", middleware.Require("redis.read", middleware.Param("key")), handlers.GetRedisStream)
+			redis.POST("/streams/:key/trim", middleware.Audit("redis.stream.trim", middleware.Param("key")), middleware.Require("redis.delete", middleware.Param("key")), handlers.TrimRedisStream)
+			redis.GET("/streams/:key/groups", middleware.Require("redis.read", middleware.Param("key")), handlers.GetRedisStreamGroups)
+			redis.POST("/streams/:key/groups", middleware.Audit("redis.stream.group.create", middleware.Param("key")), middleware.Require("redis.write", middleware.Param("key")), handlers.CreateRedisStreamGroup)
+			redis.DELETE("/streams/:key/groups/:group", middleware.Audit("redis.stream.group.delete", middleware.Param("key")), middleware.Require("redis.delete", middleware.Param("key")), handlers.DeleteRedisStreamGroup)
+			redis.GET("/streams/:key/groups/:group/consumers", middleware.Require("redis.read", middleware.Param("key")), handlers.GetRedisStreamConsumers)
+			redis.DELETE("/streams/:key/groups/:group/consumers/:consumer", middleware.Audit("redis.stream.consumer.delete", middleware.Param("key")), middleware.Require("redis.delete", middleware.Param("key")), handlers.DeleteRedisStreamConsumer)
+			redis.GET("/streams/:key/groups/:group/pending", middleware.Require("redis.read", middleware.Param("key")), handlers.GetRedisStreamPending)
+			redis.POST("/streams/:key/groups/:group/claim", middleware.Audit("redis.stream.claim", middleware.Param("key")), middleware.Require("redis.write", middleware.Param("key")), handlers.ClaimRedisStreamMessages)
+			redis.POST("/streams/:key/groups/:group/autoclaim", middleware.Audit("redis.stream.autoclaim", middleware.Param("key")), middleware.Require("redis.write", middleware.Param("key")), handlers.AutoClaimRedisStreamMessages)
+			redis.POST("/streams/:key/groups/:group/ack", middleware.Audit("redis.stream.ack", middleware.Param("key")), middleware.Require("redis.write", middleware.Param("key")), handlers.AckRedisStreamMessages)
+
+			// 集群拓扑
+			redis.GET("/cluster/nodes", middleware.Require("redis.read", middleware.Global), handlers.GetRedisClusterNodes)
+			redis.GET("/cluster/shards", middleware.Require("redis.read", middleware.Global), handlers.GetRedisClusterShards)
+			redis.GET("/cluster/slot", middleware.Require("redis.read", nil), handlers.GetRedisKeySlot)
+
+			// 发布订阅与键空间通知（WebSocket）
+			redis.GET("/pubsub", middleware.Require("redis.read", middleware.Global), handlers.RedisPubSub)
 		}
 	}
 
-	// 启动服务器
-	port := fmt.Sprintf(":%d", cfg.Server.Port)
-	r.Run(port)
+	// 启动服务器，收到 SIGINT/SIGTERM 后优雅退出
+	if err := run(newServer(r)); err != nil {
+		slog.Error("Server exited with error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// newServer 按 server 配置创建 HTTP 服务，超时未配置时使用默认值
+func newServer(handler http.Handler) *http.Server {
+	sc := config.Server()
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", sc.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: durationOr(sc.ReadHeaderTimeout, 10*time.Second),
+		// 导入和导出可能传输较大的文件，读写超时不宜过短
+		ReadTimeout:  durationOr(sc.ReadTimeout, 5*time.Minute),
+		WriteTimeout: durationOr(sc.WriteTimeout, 10*time.Minute),
+		IdleTimeout:  durationOr(sc.IdleTimeout, 2*time.Minute),
+		ErrorLog:     slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	// WebSocket 连接已被接管，Shutdown 不会等待，需要单独关闭
+	srv.RegisterOnShutdown(handlers.CloseRedisPubSubSessions)
+	return srv
+}
+
+// run 启动服务并等待退出信号，然后依次停止接收请求、等待处理中的请求、
+// 结束后台任务、停止监听配置文件，关闭 Redis 和数据库连接，最后导出剩余的 span
+// 启动失败（如端口被占用）时同样执行这些清理
+func run(srv *http.Server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return errors.Join(err, shutdown(srv))
+	case <-ctx.Done():
+	}
+	// 再次收到信号时直接退出
+	stop()
+	slog.Info("Shutting down")
+
+	if err := shutdown(srv); err != nil {
+		return err
+	}
+	slog.Info("Server stopped")
+	return nil
+}
+
+// shutdown 按顺序停止服务并释放资源，某一步失败时继续执行后面的步骤
+func shutdown(srv *http.Server) error {
+	timeout := durationOr(config.Server().ShutdownTimeout, 30*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+		srv.Close()
+	}
+	if err := handlers.StopRedisAnalyzeJobs(shutdownCtx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := config.StopWatch(); err != nil {
+		errs = append(errs, fmt.Errorf("config watcher: %w", err))
+	}
+	if err := config.CloseRedis(); err != nil {
+		errs = append(errs, fmt.Errorf("redis: %w", err))
+	}
+	if err := config.CloseDB(); err != nil {
+		errs = append(errs, fmt.Errorf("database: %w", err))
+	}
+	if err := tracing.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("tracing: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func durationOr(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
+
+// runSecretCommand 配置加密工具
+//
+//	secret keygen [-key path]          生成密钥文件
+//	secret encrypt [-key path] [value] 加密配置值，省略 value 时从标准输入读取，避免明文留在 shell 历史中
+func runSecretCommand(args []string) int {
+	usage := "usage: secret keygen [-key path] | secret encrypt [-key path] [value]"
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("secret "+args[0], flag.ContinueOnError)
+	keyPath := fs.String("key", "", "密钥文件路径，默认读取 GO_WEB_SECRET_KEY_FILE 或配置文件同目录下的 secret.key")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *keyPath == "" {
+		path, err := config.SecretKeyPath()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		*keyPath = path
+	}
+
+	switch args[0] {
+	case "keygen":
+		if err := config.GenerateSecretKey(*keyPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Secret key written to %s\n", *keyPath)
+
+	case "encrypt":
+		value := fs.Arg(0)
+		if fs.NArg() == 0 {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+		encrypted, err := config.EncryptSecret(*keyPath, value)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(encrypted)
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	return 0
 }
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	configReloads = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Config reload attempts by result (success, failure).",
+	}, []string{"result"})
+
+	configComponentFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reload_component_failures_total",
+		Help:      "Components that failed to apply a reloaded config.",
+	}, []string{"component"})
+
+	configLastSuccess = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_last_reload_success_timestamp_seconds",
+		Help:      "Unix time of the last config load that passed validation.",
+	})
+)
+
+// ConfigLoaded 记录配置通过校验并生效的时间，启动和每次重载成功时调用
+func ConfigLoaded() {
+	configLastSuccess.Set(float64(time.Now().Unix()))
+}
+
+// ConfigReloaded 记录一次重载，ok 为 false 表示加载或校验失败，沿用旧配置
+func ConfigReloaded(ok bool) {
+	if !ok {
+		configReloads.WithLabelValues("failure").Inc()
+		return
+	}
+	configReloads.WithLabelValues("success").Inc()
+	ConfigLoaded()
+}
+
+// ConfigComponentFailed 记录组件应用新配置失败
+func ConfigComponentFailed(component string) {
+	configComponentFailures.WithLabelValues(component).Inc()
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+var (
+	sqlDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sql_query_duration_seconds",
+		Help:      "SQL statement latency by handler and operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"handler", "operation"})
+
+	sqlErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sql_query_errors_total",
+		Help:      "Failed SQL statements by handler and operation, not counting record not found.",
+	}, []string{"handler", "operation"})
+)
+
+const sqlStartKey = "metrics:start"
+
+// GormPlugin 记录每条 SQL 的耗时和错误，handler 标签取自 WithContext 传入的请求 context
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, p := range processors {
+		if err := p.before("metrics:before_"+p.operation, startSQLTimer); err != nil {
+			return err
+		}
+		if err := p.after("metrics:after_"+p.operation, observeSQL(p.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startSQLTimer(db *gorm.DB) {
+	db.InstanceSet(sqlStartKey, time.Now())
+}
+
+func observeSQL(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(sqlStartKey)
+		if !ok {
+			return
+		}
+
+		handler := HandlerName(db.Statement.Context)
+		sqlDuration.WithLabelValues(handler, operation).Observe(time.Since(v.(time.Time)).Seconds())
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			sqlErrors.WithLabelValues(handler, operation).Inc()
+		}
+	}
+}
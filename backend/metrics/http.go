@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpInFlight = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+)
+
+type handlerKey struct{}
+
+// HandlerName 返回请求对应的处理函数名（如 handlers.GetUsers），不在请求中时返回空字符串
+func HandlerName(ctx context.Context) string {
+	name, _ := ctx.Value(handlerKey{}).(string)
+	return name
+}
+
+// Middleware 按路由模板统计请求数和耗时，未匹配的路由记为 unmatched，避免路径参数导致标签过多
+// 同时把处理函数名写入请求 context，供 SQL 指标按处理函数区分
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		} else {
+			ctx := context.WithValue(c.Request.Context(), handlerKey{}, path.Base(c.HandlerName()))
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		httpInFlight.Inc()
+		start := time.Now()
+		c.Next()
+		httpInFlight.Dec()
+
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
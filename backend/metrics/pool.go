@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// PoolCollector 在抓取时读取当前数据库和 Redis 连接池的状态，配置重载替换连接池后自动跟随
+type PoolCollector struct {
+	db    func() *gorm.DB
+	redis func() redis.UniversalClient
+
+	dbOpen, dbInUse, dbIdle, dbMaxOpen    *prometheus.Desc
+	dbWaitCount, dbWaitSeconds            *prometheus.Desc
+	redisTotal, redisIdle, redisStale     *prometheus.Desc
+	redisHits, redisMisses, redisTimeouts *prometheus.Desc
+}
+
+// NewPoolCollector 创建连接池指标，db 和 redis 返回当前使用的连接
+func NewPoolCollector(db func() *gorm.DB, redis func() redis.UniversalClient) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, nil)
+	}
+	return &PoolCollector{
+		db:    db,
+		redis: redis,
+
+		dbOpen:        desc("db_pool_open_connections", "Established database connections, in use and idle."),
+		dbInUse:       desc("db_pool_in_use_connections", "Database connections currently in use."),
+		dbIdle:        desc("db_pool_idle_connections", "Idle database connections."),
+		dbMaxOpen:     desc("db_pool_max_open_connections", "Maximum number of open database connections, 0 means unlimited."),
+		dbWaitCount:   desc("db_pool_wait_total", "Total number of waits for a database connection."),
+		dbWaitSeconds: desc("db_pool_wait_seconds_total", "Total time spent waiting for a database connection."),
+		redisTotal:    desc("redis_pool_connections", "Total Redis connections in the pool."),
+		redisIdle:     desc("redis_pool_idle_connections", "Idle Redis connections in the pool."),
+		redisStale:    desc("redis_pool_stale_connections_total", "Stale Redis connections removed from the pool."),
+		redisHits:     desc("redis_pool_hits_total", "Times a free Redis connection was found in the pool."),
+		redisMisses:   desc("redis_pool_misses_total", "Times a free Redis connection was not found in the pool."),
+		redisTimeouts: desc("redis_pool_timeouts_total", "Times waiting for a Redis connection timed out."),
+	}
+}
+
+func (p *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		p.dbOpen, p.dbInUse, p.dbIdle, p.dbMaxOpen, p.dbWaitCount, p.dbWaitSeconds,
+		p.redisTotal, p.redisIdle, p.redisStale, p.redisHits, p.redisMisses, p.redisTimeouts,
+	} {
+		ch <- d
+	}
+}
+
+func (p *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	// 重载后计数器从新连接池重新开始，Prometheus 会按计数器重置处理
+	if db := p.db(); db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			s := sqlDB.Stats()
+			ch <- prometheus.MustNewConstMetric(p.dbOpen, prometheus.GaugeValue, float64(s.OpenConnections))
+			ch <- prometheus.MustNewConstMetric(p.dbInUse, prometheus.GaugeValue, float64(s.InUse))
+			ch <- prometheus.MustNewConstMetric(p.dbIdle, prometheus.GaugeValue, float64(s.Idle))
+			ch <- prometheus.MustNewConstMetric(p.dbMaxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections))
+			ch <- prometheus.MustNewConstMetric(p.dbWaitCount, prometheus.CounterValue, float64(s.WaitCount))
+			ch <- prometheus.MustNewConstMetric(p.dbWaitSeconds, prometheus.CounterValue, s.WaitDuration.Seconds())
+		}
+	}
+
+	if rdb := p.redis(); rdb != nil {
+		s := rdb.PoolStats()
+		ch <- prometheus.MustNewConstMetric(p.redisTotal, prometheus.GaugeValue, float64(s.TotalConns))
+		ch <- prometheus.MustNewConstMetric(p.redisIdle, prometheus.GaugeValue, float64(s.IdleConns))
+		ch <- prometheus.MustNewConstMetric(p.redisStale, prometheus.CounterValue, float64(s.StaleConns))
+		ch <- prometheus.MustNewConstMetric(p.redisHits, prometheus.CounterValue, float64(s.Hits))
+		ch <- prometheus.MustNewConstMetric(p.redisMisses, prometheus.CounterValue, float64(s.Misses))
+		ch <- prometheus.MustNewConstMetric(p.redisTimeouts, prometheus.CounterValue, float64(s.Timeouts))
+	}
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+const (
+	auditCommandKey = "audit.command"
+
+	// maxAuditCommand 命令超过该长度时截断，避免导入等操作写入过大的记录
+	maxAuditCommand = 64 << 10
+	// maxAuditTarget 与 AuditLog.Target 的列长度一致（字符数）
+	maxAuditTarget = 255
+)
+
+// auditCommand 累积一次请求中执行的命令，超过 maxAuditCommand 后只统计长度，不再复制内容
+type auditCommand struct {
+	b     strings.Builder
+	total int
+}
+
+func (a *auditCommand) add(command string) {
+	if a.total > 0 {
+		a.write("\n")
+	}
+	a.write(command)
+}
+
+func (a *auditCommand) write(s string) {
+	a.total += len(s)
+	if room := maxAuditCommand - a.b.Len(); room > 0 {
+		a.b.WriteString(s[:min(len(s), room)])
+	}
+}
+
+// String 返回记录的命令，截断时去掉被截断的半个字符并注明总长度
+func (a *auditCommand) String() string {
+	if a.total <= a.b.Len() {
+		return a.b.String()
+	}
+	return fmt.Sprintf("%s... (truncated, %d bytes)", strings.ToValidUTF8(a.b.String(), ""), a.total)
+}
+
+// truncateAuditTarget 按字符数截断目标，超长的键名或模式不会导致写入审计记录失败
+func truncateAuditTarget(target string) string {
+	if utf8.RuneCountInString(target) <= maxAuditTarget {
+		return target
+	}
+	runes := []rune(target)
+	return string(runes[:maxAuditTarget-3]) + "..."
+}
+
+// auditWriter 记录错误响应的内容，用于提取错误信息
+type auditWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	if w.Status() >= http.StatusBadRequest && w.body.Len() < 4096 {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Audit 记录操作人、目标、实际执行的命令、结果和耗时
+// 放在 Require 之前，权限不足被拒绝的请求同样会被记录
+func Audit(action string, target ScopeFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		entry := models.AuditLog{
+			Action:     action,
+			Connection: auditConnection(action),
+			ClientIP:   c.ClientIP(),
+		}
+		if target != nil {
+			entry.Target, _ = target(c)
+		}
+
+		w := &auditWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		entry.Actor = "anonymous"
+		if claims, ok := CurrentUser(c); ok {
+			entry.Actor = claims.Email
+		}
+		entry.Duration = time.Since(start).Milliseconds()
+		entry.StatusCode = w.Status()
+		if cmd, ok := c.Get(auditCommandKey); ok {
+			entry.Command = cmd.(*auditCommand).String()
+		}
+		entry.Target = truncateAuditTarget(entry.Target)
+
+		switch {
+		case entry.StatusCode == http.StatusForbidden && entry.Command == "":
+			entry.Status = "denied"
+		case entry.StatusCode >= http.StatusBadRequest:
+			entry.Status = "error"
+		default:
+			entry.Status = "success"
+		}
+		if entry.Status != "success" {
+			var resp struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(w.body.Bytes(), &resp) == nil {
+				entry.Error = resp.Error
+			}
+		}
+
+		// 客户端断开后也要写入审计记录，不继承请求的取消
+		if err := config.GetDB().WithContext(context.WithoutCancel(c)).Create(&entry).Error; err != nil {
+			slog.ErrorContext(c, "Failed to write audit log", "action", entry.Action, "error", err)
+		}
+	}
+}
+
+// AuditCommand 设置本次请求实际执行的 SQL 或 Redis 命令，多条命令会按行追加
+func AuditCommand(c *gin.Context, command string) {
+	cmd, ok := c.Get(auditCommandKey)
+	if !ok {
+		cmd = &auditCommand{}
+		c.Set(auditCommandKey, cmd)
+	}
+	cmd.(*auditCommand).add(command)
+}
+
+// auditConnection 根据操作类型返回对应的数据库或 Redis 实例
+func auditConnection(action string) string {
+	cfg := config.GetConfig()
+	switch {
+	case strings.HasPrefix(action, "table."):
+		return fmt.Sprintf("mysql://%s:%d/%s", cfg.Database.Host, cfg.Database.Port, cfg.Database.DBName)
+	case strings.HasPrefix(action, "redis."):
+		rc := cfg.Redis
+		switch rc.Mode {
+		case "cluster":
+			return "redis-cluster://" + strings.Join(rc.Cluster.Addrs, ",")
+		case "sentinel":
+			return "redis-sentinel://" + rc.Sentinel.MasterName + "@" + strings.Join(rc.Sentinel.Addrs, ",")
+		default:
+			return fmt.Sprintf("redis://%s:%d/%d", rc.Single.Host, rc.Single.Port, rc.Single.DB)
+		}
+	default:
+		return "app"
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+const claimsKey = "auth.claims"
+
+// Claims 访问令牌内容，Subject 为用户 ID
+type Claims struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// UserID 令牌对应的用户 ID
+func (c *Claims) UserID() uint {
+	id, _ := strconv.ParseUint(c.Subject, 10, 64)
+	return uint(id)
+}
+
+// AccessTokenTTL 访问令牌有效期
+func AccessTokenTTL() time.Duration {
+	if ttl := config.Auth().AccessTokenTTL; ttl > 0 {
+		return ttl
+	}
+	return 15 * time.Minute
+}
+
+// RefreshTokenTTL 刷新令牌有效期
+func RefreshTokenTTL() time.Duration {
+	if ttl := config.Auth().RefreshTokenTTL; ttl > 0 {
+		return ttl
+	}
+	return 7 * 24 * time.Hour
+}
+
+// NewAccessToken 为用户签发 HS256 访问令牌
+func NewAccessToken(user *models.User) (string, error) {
+	auth := config.Auth()
+	if auth.JWTSecret == "" {
+		return "", errors.New("auth.jwt_secret is not configured")
+	}
+
+	now := time.Now()
+	claims := Claims{
+		Email: user.Email,
+		Name:  user.Name,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			Issuer:    auth.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(auth.JWTSecret))
+}
+
+// ParseAccessToken 校验签名、过期时间和签发者
+func ParseAccessToken(tokenString string) (*Claims, error) {
+	auth := config.Auth()
+	if auth.JWTSecret == "" {
+		return nil, errors.New("auth.jwt_secret is not configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if auth.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(auth.Issuer))
+	}
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(auth.JWTSecret), nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// Auth 校验 Authorization: Bearer 访问令牌
+// 浏览器无法为 WebSocket 设置请求头，因此升级请求也可以通过 access_token 查询参数传递
+func Auth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c)
+		if token == "" {
+			abortUnauthorized(c, "Missing access token")
+			return
+		}
+
+		claims, err := ParseAccessToken(token)
+		if err != nil {
+			abortUnauthorized(c, "Invalid access token: "+err.Error())
+			return
+		}
+
+		c.Set(claimsKey, claims)
+		c.Next()
+	}
+}
+
+// CurrentUser 获取当前请求的登录用户
+func CurrentUser(c *gin.Context) (*Claims, bool) {
+	v, ok := c.Get(claimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*Claims)
+	return claims, ok
+}
+
+func bearerToken(c *gin.Context) string {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		return c.Query("access_token")
+	}
+	return ""
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="go-web"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{Error: message, Code: "UNAUTHORIZED"})
+}
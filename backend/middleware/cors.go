@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/wgcoder2024/go-web/backend/config"
+)
+
+// CORS 按 server.cors 配置处理跨域请求，配置重载后新的策略立即生效
+func CORS() gin.HandlerFunc {
+	var handler atomic.Pointer[gin.HandlerFunc]
+
+	h, err := newCORSHandler(config.GetConfig())
+	if err != nil {
+		panic(err)
+	}
+	handler.Store(&h)
+
+	config.OnReload("cors", func(old, new *config.Config) error {
+		h, err := newCORSHandler(new)
+		if err != nil {
+			return err
+		}
+		handler.Store(&h)
+		return nil
+	})
+
+	return func(c *gin.Context) {
+		(*handler.Load())(c)
+	}
+}
+
+// newCORSHandler 先校验配置，避免 cors.New 在配置无效时 panic
+func newCORSHandler(cfg *config.Config) (gin.HandlerFunc, error) {
+	cc := cors.Config{
+		AllowOrigins:     cfg.Server.Cors.AllowedOrigins,
+		AllowMethods:     cfg.Server.Cors.AllowedMethods,
+		AllowHeaders:     cfg.Server.Cors.AllowedHeaders,
+		AllowCredentials: true,
+	}
+	if err := cc.Validate(); err != nil {
+		return nil, err
+	}
+	return cors.New(cc), nil
+}
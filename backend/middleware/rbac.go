@@ -0,0 +1,328 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wgcoder2024/go-web/backend/config"
+	"github.com/wgcoder2024/go-web/backend/models"
+)
+
+const grantsKey = "auth.grants"
+
+// Permissions 所有权限，授权时也可以使用 * 或 redis.* 这样的前缀通配
+var Permissions = []string{
+	"users.read",   // 查看用户
+	"users.write",  // 创建、修改、删除用户
+	"roles.manage", // 管理角色和用户授权
+	"audit.read",   // 查询和导出审计日志
+	"config.read",  // 查看配置热重载状态
+	"tables.read",  // 查看表结构和数据、执行 SELECT
+	"tables.ddl",   // 建表、改表、删表、导入
+	"redis.read",   // 查看键、服务器状态、Stream、集群拓扑，订阅
+	"redis.write",  // 写入键、导入、Stream 消费、执行脚本和函数
+	"redis.delete", // 删除键、消费者组和消费者
+	"redis.admin",  // 命令控制台、管理脚本和函数库、慢日志、客户端
+}
+
+// ScopeFunc 从请求中取出权限作用的资源（表名或 Redis 键）
+type ScopeFunc func(c *gin.Context) (string, error)
+
+// Param 资源来自路径参数
+func Param(name string) ScopeFunc {
+	return func(c *gin.Context) (string, error) {
+		return c.Param(name), nil
+	}
+}
+
+// BodyField 资源来自 JSON 请求体中的字段，读取后请求体会被还原供处理函数使用
+func BodyField(name string) ScopeFunc {
+	return func(c *gin.Context) (string, error) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return "", err
+		}
+		var value string
+		if raw, ok := fields[name]; ok {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return "", err
+			}
+		}
+		return value, nil
+	}
+}
+
+// tableNamePattern 表名只允许字母、数字和下划线，避免通配授权匹配到拼接进 SQL 的多个表名
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ScopeError 资源不合法或不存在，Require 按 Status 和 Code 返回错误
+type ScopeError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *ScopeError) Error() string {
+	return e.Message
+}
+
+// Table 资源来自路径参数中的表名，表名必须合法且表在当前库中存在
+func Table(param string) ScopeFunc {
+	return func(c *gin.Context) (string, error) {
+		name := c.Param(param)
+		if !tableNamePattern.MatchString(name) {
+			return "", &ScopeError{Status: http.StatusBadRequest, Code: "INVALID_TABLE", Message: "Invalid table name " + strconv.Quote(name)}
+		}
+
+		var count int64
+		err := config.GetDB().WithContext(c).Raw(`
+			SELECT COUNT(*) FROM information_schema.tables
+			WHERE table_schema = DATABASE() AND table_name = ?
+		`, name).Scan(&count).Error
+		if err != nil {
+			return "", &ScopeError{Status: http.StatusInternalServerError, Code: "INTERNAL_ERROR", Message: err.Error()}
+		}
+		if count == 0 {
+			return "", &ScopeError{Status: http.StatusNotFound, Code: "TABLE_NOT_FOUND", Message: "Table " + name + " not found"}
+		}
+		if err := requireAppTableAccess(c, name); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+}
+
+// requireAppTableAccess 应用自身的表（用户、授权、审计等）只有管理员可以访问
+func requireAppTableAccess(c *gin.Context, name string) error {
+	if !config.IsAppTable(name) {
+		return nil
+	}
+	admin, err := IsAdmin(c)
+	if err != nil {
+		return &ScopeError{Status: http.StatusInternalServerError, Code: "INTERNAL_ERROR", Message: err.Error()}
+	}
+	if !admin {
+		return &ScopeError{Status: http.StatusForbidden, Code: "FORBIDDEN", Message: "Table " + name + " is reserved for administrators"}
+	}
+	return nil
+}
+
+// NewTable 资源来自 JSON 请求体中要创建的表名，只校验表名格式
+func NewTable(field string) ScopeFunc {
+	body := BodyField(field)
+	return func(c *gin.Context) (string, error) {
+		name, err := body(c)
+		if err != nil {
+			return "", err
+		}
+		if !tableNamePattern.MatchString(name) {
+			return "", &ScopeError{Status: http.StatusBadRequest, Code: "INVALID_TABLE", Message: "Invalid table name " + strconv.Quote(name)}
+		}
+		return name, nil
+	}
+}
+
+// globMarker 代替键模式中的通配字符，只有授权范围中的 * 能匹配它，
+// 保证模式能匹配到的键都在授权范围内；例如授权 cache:* 允许模式 cache:user:*，不允许 *
+const globMarker = '\x00'
+
+// KeyPattern 资源是 Redis 键模式（如 SCAN MATCH 的参数），为空时视为 *
+func KeyPattern(from ScopeFunc) ScopeFunc {
+	return func(c *gin.Context) (string, error) {
+		pattern, err := from(c)
+		if err != nil {
+			return "", err
+		}
+		return PatternResource(pattern), nil
+	}
+}
+
+// PatternResource 将 Redis 键模式转换为权限检查使用的资源，通配字符和转义符都替换为 globMarker
+func PatternResource(pattern string) string {
+	if pattern == "" {
+		pattern = "*"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			return globMarker
+		}
+		return r
+	}, pattern)
+}
+
+// Query 资源来自查询参数
+func Query(name string) ScopeFunc {
+	return func(c *gin.Context) (string, error) {
+		return c.Query(name), nil
+	}
+}
+
+// Global 操作可能涉及任意资源（如执行 SQL、导入），只有不限范围的授权才能通过
+func Global(*gin.Context) (string, error) {
+	return "", nil
+}
+
+// Require 要求当前用户拥有 perm 权限
+// scope 为 nil 时任意范围的授权都可以通过，否则授权的 Scope 必须为空或匹配请求的资源
+func Require(perm string, scope ScopeFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		grants, err := currentGrants(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error(), Code: "INTERNAL_ERROR"})
+			return
+		}
+
+		resource := ""
+		if scope != nil {
+			if resource, err = scope(c); err != nil {
+				status, code := http.StatusBadRequest, "INVALID_JSON"
+				var se *ScopeError
+				if errors.As(err, &se) {
+					status, code = se.Status, se.Code
+				}
+				c.AbortWithStatusJSON(status, models.ErrorResponse{Error: err.Error(), Code: code})
+				return
+			}
+		}
+
+		for _, g := range grants {
+			if matchPermission(g.Permission, perm) && (scope == nil || scopeAllows(g.Scope, resource)) {
+				c.Next()
+				return
+			}
+		}
+
+		message := "Missing permission " + perm
+		if resource != "" {
+			message += " on " + strings.ReplaceAll(resource, string(globMarker), "*")
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{Error: message, Code: "FORBIDDEN"})
+	}
+}
+
+// Allowed 当前用户是否拥有作用于 resource 的 perm 权限，resource 为空时只有不限范围的授权才能通过
+// 用于处理函数中按资源过滤结果或检查请求内的后续操作
+func Allowed(c *gin.Context, perm, resource string) (bool, error) {
+	grants, err := currentGrants(c)
+	if err != nil {
+		return false, err
+	}
+	for _, g := range grants {
+		if matchPermission(g.Permission, perm) && scopeAllows(g.Scope, resource) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsAdmin 当前用户是否拥有不限范围的 * 授权
+func IsAdmin(c *gin.Context) (bool, error) {
+	return Allowed(c, "*", "")
+}
+
+// UserIsAdmin 指定用户是否拥有不限范围的 * 授权
+func UserIsAdmin(ctx context.Context, userID uint) (bool, error) {
+	grants, err := UserGrants(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, g := range grants {
+		if matchPermission(g.Permission, "*") && g.Scope == "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// scopeAllows 授权范围为空时不限资源，否则资源不能为空且要匹配授权范围
+func scopeAllows(scope, resource string) bool {
+	return scope == "" || (resource != "" && matchScope(scope, resource))
+}
+
+// UserGrants 查询用户所有角色的权限
+func UserGrants(ctx context.Context, userID uint) ([]models.RolePermission, error) {
+	var grants []models.RolePermission
+	err := config.GetDB().WithContext(ctx).Model(&models.RolePermission{}).
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Find(&grants).Error
+	return grants, err
+}
+
+// currentGrants 当前用户的权限，同一请求内只查询一次
+func currentGrants(c *gin.Context) ([]models.RolePermission, error) {
+	if v, ok := c.Get(grantsKey); ok {
+		return v.([]models.RolePermission), nil
+	}
+
+	claims, ok := CurrentUser(c)
+	if !ok {
+		return nil, nil
+	}
+	grants, err := UserGrants(c, claims.UserID())
+	if err != nil {
+		return nil, err
+	}
+	c.Set(grantsKey, grants)
+	return grants, nil
+}
+
+// ValidPermission 是否为已知权限或通配
+func ValidPermission(perm string) bool {
+	for _, p := range Permissions {
+		if matchPermission(perm, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPermission 授权 granted 是否包含 perm，支持 * 和 redis.* 形式
+func matchPermission(granted, perm string) bool {
+	if granted == "*" || granted == perm {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(granted, ".*")
+	return ok && strings.HasPrefix(perm, prefix+".")
+}
+
+// matchScope 通配符匹配，* 匹配任意字符（包括 / 和 :），? 匹配单个字符（不匹配 globMarker）
+func matchScope(pattern, value string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(value); i >= 0; i-- {
+				if matchScope(pattern[1:], value[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(value) == 0 || value[0] == globMarker {
+				return false
+			}
+			pattern, value = pattern[1:], value[1:]
+		default:
+			if len(value) == 0 || pattern[0] != value[0] {
+				return false
+			}
+			pattern, value = pattern[1:], value[1:]
+		}
+	}
+	return len(value) == 0
+}
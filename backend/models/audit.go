@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// AuditLog 破坏性操作的审计记录
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Actor      string    `json:"actor" gorm:"size:255;index"`
+	Action     string    `json:"action" gorm:"size:64;index"`  // 如 table.delete、redis.set
+	Connection string    `json:"connection" gorm:"size:255"`   // 操作的数据库或 Redis 实例
+	Target     string    `json:"target" gorm:"size:255;index"` // 表名或 Redis 键
+	Command    string    `json:"command" gorm:"type:mediumtext"`
+	Status     string    `json:"status" gorm:"size:16;index"` // success、error、denied
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error" gorm:"type:text"`
+	Duration   int64     `json:"duration"` // 毫秒
+	ClientIP   string    `json:"client_ip" gorm:"size:64"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+// AuditLogPage 审计记录分页结果
+type AuditLogPage struct {
+	Items      []AuditLog `json:"items"`
+	Total      int64      `json:"total"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"pageSize"`
+	TotalPages int        `json:"totalPages"`
+}
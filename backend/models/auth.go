@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenPair 登录或刷新返回的令牌，ExpiresIn 为访问令牌有效期（秒）
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	User         User   `json:"user"`
+}
+
+// RefreshToken 刷新令牌，只保存 SHA-256 哈希
+// 每次刷新都会吊销旧令牌并在同一 FamilyID 下签发新令牌，旧令牌被重复使用时整个 family 失效
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	FamilyID  string     `json:"family_id" gorm:"size:32;index"`
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
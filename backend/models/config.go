@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ConfigReloadStatus 配置文件及最近一次热重载的结果
+type ConfigReloadStatus struct {
+	Files      []string                `json:"files"`
+	LoadedAt   time.Time               `json:"loaded_at"`             // 当前生效配置的加载时间
+	LastReload *time.Time              `json:"last_reload,omitempty"` // 最近一次尝试重载的时间
+	Success    bool                    `json:"success"`               // 最近一次重载是否完全成功
+	Error      string                  `json:"error,omitempty"`       // 加载或校验失败的原因，此时沿用旧配置
+	Reloads    int                     `json:"reloads"`
+	Failures   int                     `json:"failures"`
+	Components []ConfigComponentStatus `json:"components"`
+}
+
+// ConfigComponentStatus 单个组件应用新配置的结果，失败时组件保留原有连接
+type ConfigComponentStatus struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
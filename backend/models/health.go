@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// HealthStatus 存活/就绪检查结果，Status 为 ok 时返回 200，否则返回 503
+type HealthStatus struct {
+	Status string                      `json:"status"` // ok、fail
+	Time   time.Time                   `json:"time"`
+	Checks map[string]DependencyStatus `json:"checks,omitempty"`
+	Config *ConfigHealth               `json:"config,omitempty"`
+}
+
+// ConfigHealth 就绪检查中公开的配置重载结果，只包含各组件是否成功
+// 错误详情可能包含配置值，只通过需要认证的 /api/v1/config/status 返回
+type ConfigHealth struct {
+	Status     string            `json:"status"` // ok、fail，最近一次重载是否完全成功
+	LoadedAt   time.Time         `json:"loaded_at"`
+	Components map[string]string `json:"components,omitempty"` // 组件名 -> ok、fail
+}
+
+// DependencyStatus 单个依赖的检查结果
+type DependencyStatus struct {
+	Status  string      `json:"status"`  // ok、fail
+	Latency float64     `json:"latency"` // 毫秒
+	Error   string      `json:"-"`       // 可能包含主机名、端口和用户名，只记录在服务端日志中
+	Pool    interface{} `json:"pool,omitempty"`
+}
+
+// DBPoolStats 数据库连接池统计，对应 sql.DBStats
+type DBPoolStats struct {
+	MaxOpen           int     `json:"max_open"`
+	Open              int     `json:"open"`
+	InUse             int     `json:"in_use"`
+	Idle              int     `json:"idle"`
+	WaitCount         int64   `json:"wait_count"`
+	WaitDuration      float64 `json:"wait_duration"` // 毫秒
+	MaxIdleClosed     int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64   `json:"max_lifetime_closed"`
+}
+
+// RedisPoolStats Redis 连接池统计，集群模式下为所有节点之和
+type RedisPoolStats struct {
+	Hits       uint32 `json:"hits"`
+	Misses     uint32 `json:"misses"`
+	Timeouts   uint32 `json:"timeouts"`
+	TotalConns uint32 `json:"total_conns"`
+	IdleConns  uint32 `json:"idle_conns"`
+	StaleConns uint32 `json:"stale_conns"`
+}
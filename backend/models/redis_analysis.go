@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+type RedisAnalyzeRequest struct {
+	Pattern   string `json:"pattern"`
+	Delimiter string `json:"delimiter"`
+	Depth     int    `json:"depth"`
+	TopN      int    `json:"topN"`
+	ScanCount int64  `json:"scanCount"`
+	MaxKeys   int64  `json:"maxKeys"`
+	Samples   int    `json:"samples"`
+	HotKeys   bool   `json:"hotKeys"`
+}
+
+// RedisAnalyzeJob 后台分析任务
+// Status 取值：running、completed、failed、canceled
+type RedisAnalyzeJob struct {
+	ID         string              `json:"id"`
+	Status     string              `json:"status"`
+	Request    RedisAnalyzeRequest `json:"request"`
+	Scanned    int64               `json:"scanned"`
+	Error      string              `json:"error,omitempty"`
+	StartedAt  time.Time           `json:"startedAt"`
+	FinishedAt *time.Time          `json:"finishedAt,omitempty"`
+	Result     *RedisAnalysis      `json:"result,omitempty"`
+}
+
+type RedisAnalysis struct {
+	TotalKeys   int64             `json:"totalKeys"`
+	TotalMemory int64             `json:"totalMemory"`
+	BiggestKeys []RedisKeyStat    `json:"biggestKeys"`
+	HotKeys     []RedisKeyStat    `json:"hotKeys,omitempty"`
+	Prefixes    []RedisPrefixStat `json:"prefixes"`
+	Types       []RedisTypeStat   `json:"types"`
+	TTL         []RedisTTLBucket  `json:"ttl"`
+	Warnings    []string          `json:"warnings,omitempty"`
+}
+
+type RedisKeyStat struct {
+	Key      string `json:"key"`
+	Node     string `json:"node,omitempty"`
+	Type     string `json:"type"`
+	Memory   int64  `json:"memory"`
+	Elements int64  `json:"elements"` // list、set、zset、hash、stream 的元素数量
+	Bytes    int64  `json:"bytes"`    // string 值的字节数（STRLEN）
+	TTL      int64  `json:"ttl"`      // 毫秒，-1 表示永不过期
+	Freq     int64  `json:"freq,omitempty"`
+}
+
+type RedisPrefixStat struct {
+	Prefix   string `json:"prefix"`
+	Keys     int64  `json:"keys"`
+	Memory   int64  `json:"memory"`
+	Elements int64  `json:"elements"`
+	Bytes    int64  `json:"bytes"`
+}
+
+type RedisTypeStat struct {
+	Type     string `json:"type"`
+	Keys     int64  `json:"keys"`
+	Memory   int64  `json:"memory"`
+	Elements int64  `json:"elements"`
+	Bytes    int64  `json:"bytes"`
+}
+
+type RedisTTLBucket struct {
+	Bucket string `json:"bucket"`
+	Keys   int64  `json:"keys"`
+	Memory int64  `json:"memory"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "encoding/json"
+
+// RedisKeyBackup 导出文件中的一行（JSON Lines）
+// JSON 格式下 Value 按类型保存完整的值；dump 格式下 Dump 保存 DUMP 命令的 base64 结果
+// Encoding 为 base64 时 Key 和 Value 中的所有字符串都是 base64 编码的，用于含有非 UTF-8 字节的键
+type RedisKeyBackup struct {
+	Key      string          `json:"key"`
+	Type     string          `json:"type"`
+	TTL      int64           `json:"ttl"` // 毫秒，0 表示永不过期
+	Encoding string          `json:"encoding,omitempty"`
+	Value    json.RawMessage `json:"value,omitempty"`
+	Dump     string          `json:"dump,omitempty"`
+}
+
+type RedisZMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+type RedisStreamEntry struct {
+	ID     string                 `json:"id"`
+	Values map[string]interface{} `json:"values"`
+}
+
+type RedisImportResult struct {
+	Imported int64    `json:"imported"`
+	Skipped  int64    `json:"skipped"`
+	Renamed  int64    `json:"renamed"`
+	Failed   int64    `json:"failed"`
+	Errors   []string `json:"errors,omitempty"`
+}
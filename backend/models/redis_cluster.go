@@ -0,0 +1,77 @@
+package models
+
+type RedisSlotRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// RedisClusterNode CLUSTER NODES 中的一个节点
+type RedisClusterNode struct {
+	ID          string           `json:"id"`
+	Addr        string           `json:"addr"`
+	BusPort     string           `json:"busPort,omitempty"`
+	Hostname    string           `json:"hostname,omitempty"`
+	Flags       []string         `json:"flags"`
+	Role        string           `json:"role"` // master 或 replica
+	MasterID    string           `json:"masterId,omitempty"`
+	PingSent    int64            `json:"pingSent"`
+	PongRecv    int64            `json:"pongRecv"`
+	ConfigEpoch int64            `json:"configEpoch"`
+	LinkState   string           `json:"linkState"`
+	Slots       []RedisSlotRange `json:"slots,omitempty"`
+	SlotCount   int64            `json:"slotCount"`
+	Migrating   []string         `json:"migrating,omitempty"`
+	Importing   []string         `json:"importing,omitempty"`
+	Failing     bool             `json:"failing"`        // fail 或 pfail
+	Keys        *int64           `json:"keys,omitempty"` // 仅主节点
+	Replicas    []string         `json:"replicas,omitempty"`
+}
+
+// RedisClusterSummary 集群整体状态，用于判断槽位是否完整、负载是否均衡
+type RedisClusterSummary struct {
+	State                  string   `json:"state"`
+	SlotsAssigned          int64    `json:"slotsAssigned"`
+	SlotsOK                int64    `json:"slotsOk"`
+	SlotsPFail             int64    `json:"slotsPfail"`
+	SlotsFail              int64    `json:"slotsFail"`
+	CurrentEpoch           int64    `json:"currentEpoch"`
+	Masters                int      `json:"masters"`
+	Replicas               int      `json:"replicas"`
+	FailingNodes           []string `json:"failingNodes"`
+	MastersWithoutReplicas []string `json:"mastersWithoutReplicas"`
+	MinSlots               int64    `json:"minSlots"`
+	MaxSlots               int64    `json:"maxSlots"`
+	MinKeys                int64    `json:"minKeys"`
+	MaxKeys                int64    `json:"maxKeys"`
+	KeysImbalance          float64  `json:"keysImbalance"` // 最大键数 / 平均键数
+}
+
+type RedisClusterTopology struct {
+	Nodes   []RedisClusterNode  `json:"nodes"`
+	Summary RedisClusterSummary `json:"summary"`
+}
+
+type RedisShardNode struct {
+	ID                string `json:"id"`
+	Endpoint          string `json:"endpoint"`
+	IP                string `json:"ip"`
+	Hostname          string `json:"hostname,omitempty"`
+	Port              int64  `json:"port"`
+	TLSPort           int64  `json:"tlsPort,omitempty"`
+	Role              string `json:"role"`
+	ReplicationOffset int64  `json:"replicationOffset"`
+	Health            string `json:"health"`
+}
+
+type RedisClusterShard struct {
+	Slots []RedisSlotRange `json:"slots"`
+	Nodes []RedisShardNode `json:"nodes"`
+}
+
+type RedisKeySlot struct {
+	Key      string   `json:"key"`
+	Slot     int64    `json:"slot"`
+	Master   string   `json:"master"`
+	MasterID string   `json:"masterId"`
+	Replicas []string `json:"replicas"`
+}
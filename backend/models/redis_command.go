@@ -0,0 +1,33 @@
+package models
+
+type RedisCommand struct {
+	Command string `json:"command" binding:"required"`
+	Node    string `json:"node"`
+}
+
+// RedisReply 按类型展开的 Redis 回复
+// Type 取值：nil、bulk、integer、double、boolean、bignum、error、array、map
+type RedisReply struct {
+	Type     string            `json:"type"`
+	Value    interface{}       `json:"value"`
+	Elements []RedisReply      `json:"elements,omitempty"`
+	Entries  []RedisReplyEntry `json:"entries,omitempty"`
+}
+
+type RedisReplyEntry struct {
+	Key   RedisReply `json:"key"`
+	Value RedisReply `json:"value"`
+}
+
+type RedisNodeReply struct {
+	Node  string     `json:"node"`
+	Reply RedisReply `json:"reply"`
+}
+
+type RedisCommandResult struct {
+	Args     []string         `json:"args"`
+	Node     string           `json:"node,omitempty"`
+	Reply    *RedisReply      `json:"reply,omitempty"`
+	Nodes    []RedisNodeReply `json:"nodes,omitempty"`
+	Duration int64            `json:"duration"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+type RedisNodeInfo struct {
+	Node     string                       `json:"node"`
+	Role     string                       `json:"role"`
+	Sections map[string]map[string]string `json:"sections"`
+}
+
+// RedisSummary 按集群汇总的 INFO 指标，键数量只统计主节点
+type RedisSummary struct {
+	Mode             string   `json:"mode"`
+	Nodes            int      `json:"nodes"`
+	Masters          int      `json:"masters"`
+	Replicas         int      `json:"replicas"`
+	Versions         []string `json:"versions"`
+	Keys             int64    `json:"keys"`
+	Expires          int64    `json:"expires"`
+	UsedMemory       int64    `json:"usedMemory"`
+	MaxMemory        int64    `json:"maxMemory"`
+	ConnectedClients int64    `json:"connectedClients"`
+	BlockedClients   int64    `json:"blockedClients"`
+	OpsPerSec        int64    `json:"opsPerSec"`
+	KeyspaceHits     int64    `json:"keyspaceHits"`
+	KeyspaceMisses   int64    `json:"keyspaceMisses"`
+	HitRate          float64  `json:"hitRate"`
+}
+
+type RedisInfoResult struct {
+	Nodes   []RedisNodeInfo `json:"nodes"`
+	Summary RedisSummary    `json:"summary"`
+}
+
+type RedisSlowLogEntry struct {
+	ID         int64     `json:"id"`
+	Time       time.Time `json:"time"`
+	Duration   int64     `json:"duration"` // 微秒
+	Args       []string  `json:"args"`
+	ClientAddr string    `json:"clientAddr"`
+	ClientName string    `json:"clientName"`
+}
+
+type RedisNodeSlowLog struct {
+	Node    string              `json:"node"`
+	Entries []RedisSlowLogEntry `json:"entries"`
+}
+
+type RedisNodeClients struct {
+	Node    string              `json:"node"`
+	Clients []map[string]string `json:"clients"`
+}
+
+type RedisClientKill struct {
+	Node string `json:"node"`
+	ID   int64  `json:"id"`
+	Addr string `json:"addr"`
+}
+
+type RedisNodeMemory struct {
+	Node  string                 `json:"node"`
+	Stats map[string]interface{} `json:"stats"`
+}
+
+type RedisNodeDBSize struct {
+	Node string `json:"node"`
+	Keys int64  `json:"keys"`
+}
+
+type RedisDBSizeResult struct {
+	Nodes []RedisNodeDBSize `json:"nodes"`
+	Total int64             `json:"total"`
+}
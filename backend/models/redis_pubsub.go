@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// RedisPubSubRequest 客户端通过 WebSocket 发送的指令
+// Action 取值：subscribe、unsubscribe、psubscribe、punsubscribe、keyspace、publish、ping
+type RedisPubSubRequest struct {
+	Action   string   `json:"action"`
+	Channels []string `json:"channels"`
+	Channel  string   `json:"channel"`
+	Message  string   `json:"message"`
+	Pattern  string   `json:"pattern"`
+	Events   bool     `json:"events"`
+	DB       int      `json:"db"`
+}
+
+// RedisPubSubFrame 服务端推送给客户端的消息帧
+// Type 取值：message、pmessage、subscribed、unsubscribed、published、warning、error、pong
+type RedisPubSubFrame struct {
+	Type      string    `json:"type"`
+	Channel   string    `json:"channel,omitempty"`
+	Pattern   string    `json:"pattern,omitempty"`
+	Payload   string    `json:"payload,omitempty"`
+	Node      string    `json:"node,omitempty"`
+	Channels  []string  `json:"channels,omitempty"`
+	Receivers *int64    `json:"receivers,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Time      time.Time `json:"time"`
+}
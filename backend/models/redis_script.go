@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// RedisScript 保存的 Lua 脚本
+type RedisScript struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"size:128;uniqueIndex" binding:"required"`
+	Description string    `json:"description"`
+	Body        string    `json:"body" gorm:"type:text" binding:"required"`
+	SHA1        string    `json:"sha1" gorm:"size:40"`
+	ReadOnly    bool      `json:"readOnly"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type RedisScriptRun struct {
+	Keys []string `json:"keys"`
+	Args []string `json:"args"`
+}
+
+type RedisScriptNodeStatus struct {
+	Node   string `json:"node"`
+	Loaded bool   `json:"loaded"`
+	Error  string `json:"error,omitempty"`
+}
+
+type RedisScriptDetail struct {
+	RedisScript
+	Nodes []RedisScriptNodeStatus `json:"nodes"`
+}
+
+type RedisFunctionLoad struct {
+	Code    string `json:"code" binding:"required"`
+	Replace bool   `json:"replace"`
+}
+
+type RedisFunctionCall struct {
+	Function string   `json:"function" binding:"required"`
+	Keys     []string `json:"keys"`
+	Args     []string `json:"args"`
+	ReadOnly bool     `json:"readOnly"`
+}
+
+type RedisFunctionInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Flags       []string `json:"flags"`
+}
+
+type RedisFunctionLibrary struct {
+	Name      string              `json:"name"`
+	Engine    string              `json:"engine"`
+	Functions []RedisFunctionInfo `json:"functions"`
+	Code      string              `json:"code,omitempty"`
+}
+
+type RedisNodeFunctions struct {
+	Node      string                 `json:"node"`
+	Libraries []RedisFunctionLibrary `json:"libraries"`
+}
@@ -0,0 +1,84 @@
+package models
+
+type RedisStreamInfo struct {
+	Length               int64             `json:"length"`
+	RadixTreeKeys        int64             `json:"radixTreeKeys"`
+	RadixTreeNodes       int64             `json:"radixTreeNodes"`
+	Groups               int64             `json:"groups"`
+	LastGeneratedID      string            `json:"lastGeneratedId"`
+	MaxDeletedEntryID    string            `json:"maxDeletedEntryId"`
+	EntriesAdded         int64             `json:"entriesAdded"`
+	RecordedFirstEntryID string            `json:"recordedFirstEntryId"`
+	FirstEntry           *RedisStreamEntry `json:"firstEntry,omitempty"`
+	LastEntry            *RedisStreamEntry `json:"lastEntry,omitempty"`
+}
+
+type RedisStreamGroup struct {
+	Name            string `json:"name"`
+	Consumers       int64  `json:"consumers"`
+	Pending         int64  `json:"pending"`
+	LastDeliveredID string `json:"lastDeliveredId"`
+	EntriesRead     int64  `json:"entriesRead"`
+	Lag             int64  `json:"lag"`
+}
+
+type RedisStreamConsumer struct {
+	Name     string `json:"name"`
+	Pending  int64  `json:"pending"`
+	Idle     int64  `json:"idle"`     // 毫秒
+	Inactive int64  `json:"inactive"` // 毫秒
+}
+
+type RedisStreamPendingSummary struct {
+	Count     int64            `json:"count"`
+	Lower     string           `json:"lower"`
+	Higher    string           `json:"higher"`
+	Consumers map[string]int64 `json:"consumers"`
+}
+
+type RedisStreamPendingEntry struct {
+	ID         string `json:"id"`
+	Consumer   string `json:"consumer"`
+	Idle       int64  `json:"idle"` // 毫秒
+	RetryCount int64  `json:"retryCount"`
+}
+
+type RedisStreamPending struct {
+	Summary RedisStreamPendingSummary `json:"summary"`
+	Entries []RedisStreamPendingEntry `json:"entries"`
+}
+
+type RedisStreamGroupCreate struct {
+	Group    string `json:"group" binding:"required"`
+	Start    string `json:"start"`
+	MkStream bool   `json:"mkStream"`
+}
+
+type RedisStreamClaim struct {
+	Consumer string   `json:"consumer" binding:"required"`
+	MinIdle  int64    `json:"minIdle"` // 毫秒
+	IDs      []string `json:"ids" binding:"required,min=1"`
+}
+
+type RedisStreamAutoClaim struct {
+	Consumer string `json:"consumer" binding:"required"`
+	MinIdle  int64  `json:"minIdle"` // 毫秒
+	Start    string `json:"start"`
+	Count    int64  `json:"count"`
+}
+
+type RedisStreamAutoClaimResult struct {
+	Messages  []RedisStreamEntry `json:"messages"`
+	NextStart string             `json:"nextStart"`
+}
+
+type RedisStreamAck struct {
+	IDs []string `json:"ids" binding:"required,min=1"`
+}
+
+type RedisStreamTrim struct {
+	Strategy  string `json:"strategy" binding:"required,oneof=maxlen minid"`
+	Threshold string `json:"threshold" binding:"required"`
+	Approx    bool   `json:"approx"`
+	Limit     int64  `json:"limit"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Role 角色，拥有一组权限
+type Role struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	Name        string           `json:"name" gorm:"size:64;uniqueIndex"`
+	Description string           `json:"description" gorm:"size:255"`
+	Permissions []RolePermission `json:"permissions" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// RolePermission 角色权限，Scope 为表名或 Redis 键的通配符（如 orders_*、session:*），为空表示不限
+type RolePermission struct {
+	ID         uint   `json:"-" gorm:"primaryKey"`
+	RoleID     uint   `json:"-" gorm:"index"`
+	Permission string `json:"permission" gorm:"size:64" binding:"required,max=64"`
+	Scope      string `json:"scope" gorm:"size:255" binding:"max=255"`
+}
+
+// UserRole 用户与角色的关联
+type UserRole struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey"`
+	RoleID    uint      `json:"role_id" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RoleRequest 创建或修改角色，Permissions 会整体替换原有权限
+type RoleRequest struct {
+	Name        string           `json:"name" binding:"required,max=64"`
+	Description string           `json:"description" binding:"max=255"`
+	Permissions []RolePermission `json:"permissions" binding:"dive"`
+}
+
+// UserRolesUpdate 替换用户的角色
+type UserRolesUpdate struct {
+	Roles []string `json:"roles" binding:"required"`
+}
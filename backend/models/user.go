@@ -1,12 +1,75 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Age       int       `json:"age"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Name         string         `json:"name" gorm:"size:100;not null"`
+	Email        string         `json:"email" gorm:"size:255;not null;uniqueIndex"`
+	Age          int            `json:"age"`
+	PasswordHash string         `json:"-" gorm:"size:255"` // bcrypt
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// UserPage 用户分页结果，游标分页时 NextCursor 用于获取下一页
+type UserPage struct {
+	Items      []User `json:"items"`
+	Total      int64  `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"pageSize"`
+	TotalPages int    `json:"totalPages"`
+	HasMore    bool   `json:"hasMore"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// UserCreate 创建用户请求
+type UserCreate struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Age      int    `json:"age" binding:"gte=0,lte=150"`
+	Password string `json:"password" binding:"omitempty,min=8,max=72"`
+}
+
+// UserUpdate 更新用户请求，只包含可修改字段，未提供的字段保持不变
+type UserUpdate struct {
+	Name     *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Email    *string `json:"email" binding:"omitempty,email,max=255"`
+	Age      *int    `json:"age" binding:"omitempty,gte=0,lte=150"`
+	Password *string `json:"password" binding:"omitempty,min=8,max=72"`
+}
+
+// FieldError 字段校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse 带错误码的错误响应
+type ErrorResponse struct {
+	Error  string       `json:"error"`
+	Code   string       `json:"code"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// UserAudit 用户变更记录，Before/After 为变更前后的用户快照
+type UserAudit struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	UserID    uint            `json:"user_id" gorm:"index"`
+	Action    string          `json:"action" gorm:"size:16"` // create、update、delete、restore
+	Actor     string          `json:"actor" gorm:"size:128"`
+	Before    json.RawMessage `json:"before" gorm:"type:json"`
+	After     json.RawMessage `json:"after" gorm:"type:json"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func (UserAudit) TableName() string {
+	return "user_audit"
 }
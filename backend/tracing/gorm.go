@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const sqlSpanKey = "tracing:span"
+
+// maxSQLText db.query.text 的最大长度，导入等操作的 SQL 可能很长
+const maxSQLText = 2048
+
+// GormPlugin 为每条 SQL 创建客户端 span，父 span 取自 WithContext 传入的请求 context
+// SQL 使用 ? 占位符，不记录参数；SQL 控制台和表导入执行的原始 SQL 中的字面量同样替换为 ?
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, p := range processors {
+		if err := p.before("tracing:before_"+p.operation, startSQLSpan(p.operation)); err != nil {
+			return err
+		}
+		if err := p.after("tracing:after_"+p.operation, endSQLSpan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startSQLSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		ctx, span := tracer().Start(db.Statement.Context, "sql."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemMySQL),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(sqlSpanKey, span)
+	}
+}
+
+func endSQLSpan(db *gorm.DB) {
+	v, ok := db.InstanceGet(sqlSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+	defer span.End()
+
+	// SQL 在执行时才生成，span 名称改为 SELECT users 这样的操作加表名
+	sql := db.Statement.SQL.String()
+	op, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	op = strings.ToUpper(op)
+	name := op
+	if table := db.Statement.Table; table != "" {
+		name += " " + table
+		span.SetAttributes(semconv.DBCollectionName(table))
+	}
+	if name != "" {
+		span.SetName(name)
+	}
+	span.SetAttributes(
+		semconv.DBOperationName(op),
+		semconv.DBQueryText(sanitizeSQL(sql)),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
+
+// sanitizeSQL 将字符串和数字字面量替换为 ?，并截断到 maxSQLText，避免密码哈希、导入的行数据等值导出到采集端
+// 反引号中的标识符和名称中的数字保持不变
+func sanitizeSQL(sql string) string {
+	var b strings.Builder
+	for i := 0; i < len(sql) && b.Len() < maxSQLText; {
+		ch := sql[i]
+		switch {
+		case ch == '\'' || ch == '"':
+			// 跳过到匹配的引号，支持反斜杠转义和连续两个引号
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == '\\' {
+					j += 2
+					continue
+				}
+				if sql[j] == ch {
+					if j+1 < len(sql) && sql[j+1] == ch {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			b.WriteByte('?')
+			i = j + 1
+		case ch == '`':
+			j := strings.IndexByte(sql[i+1:], '`')
+			if j < 0 {
+				j = len(sql) - i - 1
+			}
+			b.WriteString(sql[i : i+j+2])
+			i += j + 2
+		case isSQLDigit(ch) && (i == 0 || !isSQLWordChar(sql[i-1])):
+			j := i
+			for j < len(sql) && (isSQLWordChar(sql[j]) || sql[j] == '.') {
+				j++
+			}
+			b.WriteByte('?')
+			i = j
+		default:
+			b.WriteByte(ch)
+			i++
+		}
+	}
+
+	out := b.String()
+	if len(out) > maxSQLText {
+		out = out[:maxSQLText]
+	}
+	if len(out) < len(sql) && b.Len() >= maxSQLText {
+		out = strings.ToValidUTF8(out, "") + "..."
+	}
+	return out
+}
+
+func isSQLDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isSQLWordChar(ch byte) bool {
+	return isSQLDigit(ch) || ch == '_' || ch == '$' || (ch|0x20 >= 'a' && ch|0x20 <= 'z') || ch >= 0x80
+}
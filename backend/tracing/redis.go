@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook 为每个 Redis 命令和 pipeline 创建客户端 span，只记录命令名，不记录键和值
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ctx, span := tracer().Start(ctx, "redis dial",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, attribute.String("server.address", addr)),
+		)
+		defer span.End()
+
+		conn, err := next(ctx, network, addr)
+		endRedisSpan(span, err)
+		return conn, err
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		op := strings.ToUpper(cmd.Name())
+		ctx, span := tracer().Start(ctx, op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(op)),
+		)
+		defer span.End()
+
+		err := next(ctx, cmd)
+		endRedisSpan(span, err)
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		// 分析任务的 pipeline 可能有上千条命令，只记录不重复的命令名和数量
+		var ops []string
+		seen := make(map[string]bool)
+		for _, cmd := range cmds {
+			if op := strings.ToUpper(cmd.Name()); !seen[op] {
+				seen[op] = true
+				ops = append(ops, op)
+			}
+		}
+		ctx, span := tracer().Start(ctx, "PIPELINE",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				semconv.DBOperationName("PIPELINE"),
+				attribute.StringSlice("db.redis.commands", ops),
+				attribute.Int("db.redis.pipeline_size", len(cmds)),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		endRedisSpan(span, err)
+		return err
+	}
+}
+
+func endRedisSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 本应用创建的 span 所属的 tracer 名
+const instrumentationName = "github.com/wgcoder2024/go-web/backend/tracing"
+
+// Options 追踪配置，Exporter 为空或 none 时不导出 span
+type Options struct {
+	// Exporter otlp、stdout 或 none
+	Exporter string
+	// Protocol OTLP 协议，grpc（默认）或 http
+	Protocol string
+	// Endpoint OTLP 地址（host:port），为空时读取 OTEL_EXPORTER_OTLP_ENDPOINT，默认 localhost:4317/4318
+	Endpoint string
+	// Insecure 不使用 TLS 连接 OTLP
+	Insecure bool
+	// SampleRatio 采样比例，0 到 1，为 nil 时全部采样；上游已决定采样时沿用上游的决定
+	SampleRatio *float64
+	// ServiceName 上报的 service.name，为空时使用 go-web
+	ServiceName string
+}
+
+var provider atomic.Pointer[sdktrace.TracerProvider]
+
+// tracer 每次从全局 TracerProvider 获取，Setup 之前创建的 span 不会被记录
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup 创建 TracerProvider 并设置为全局，同时设置 W3C traceparent/baggage 传播
+// 未启用导出时仍会从请求头传播上游的 trace 上下文
+func Setup(ctx context.Context, opts Options) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(ctx, opts)
+	if err != nil || exporter == nil {
+		return err
+	}
+
+	name := opts.ServiceName
+	if name == "" {
+		name = "go-web"
+	}
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithAttributes(semconv.ServiceName(name)),
+	)
+	if err != nil {
+		return err
+	}
+
+	ratio := 1.0
+	if opts.SampleRatio != nil {
+		ratio = *opts.SampleRatio
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+	provider.Store(tp)
+	return nil
+}
+
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, error) {
+	switch opts.Exporter {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		switch opts.Protocol {
+		case "", "grpc":
+			var o []otlptracegrpc.Option
+			if opts.Endpoint != "" {
+				o = append(o, otlptracegrpc.WithEndpoint(opts.Endpoint))
+			}
+			if opts.Insecure {
+				o = append(o, otlptracegrpc.WithInsecure())
+			}
+			return otlptracegrpc.New(ctx, o...)
+		case "http":
+			var o []otlptracehttp.Option
+			if opts.Endpoint != "" {
+				o = append(o, otlptracehttp.WithEndpoint(opts.Endpoint))
+			}
+			if opts.Insecure {
+				o = append(o, otlptracehttp.WithInsecure())
+			}
+			return otlptracehttp.New(ctx, o...)
+		}
+		return nil, fmt.Errorf("unknown OTLP protocol %q", opts.Protocol)
+	}
+	return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+}
+
+// Shutdown 导出尚未发送的 span 并关闭 TracerProvider，未启用导出时直接返回
+func Shutdown(ctx context.Context) error {
+	if tp := provider.Swap(nil); tp != nil {
+		return tp.Shutdown(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+import { ref } from 'vue'
+import router from './router'
+
+const AUTH_URL = 'http://localhost:8080/api/v1/auth'
+const STORAGE_KEY = 'go-web-auth'
+
+const stored = JSON.parse(localStorage.getItem(STORAGE_KEY) || 'null')
+
+export const currentUser = ref(stored?.user || null)
+
+let accessToken = stored?.access_token || ''
+let refreshToken = stored?.refresh_token || ''
+let refreshing = null
+
+const saveTokens = (data) => {
+  accessToken = data.access_token
+  refreshToken = data.refresh_token
+  currentUser.value = data.user
+  localStorage.setItem(STORAGE_KEY, JSON.stringify(data))
+}
+
+const clearTokens = () => {
+  accessToken = ''
+  refreshToken = ''
+  currentUser.value = null
+  localStorage.removeItem(STORAGE_KEY)
+}
+
+export const isLoggedIn = () => !!accessToken
+
+export const getAccessToken = () => accessToken
+
+// 登录
+export const login = async (email, password) => {
+  const response = await fetch(`${AUTH_URL}/login`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify({ email, password }),
+  })
+
+  const data = await response.json()
+  if (!response.ok) {
+    throw new Error(data.error)
+  }
+  saveTokens(data)
+}
+
+// 退出登录
+export const logout = async () => {
+  try {
+    if (refreshToken) {
+      await fetch(`${AUTH_URL}/logout`, {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      })
+    }
+  } catch (error) {
+    console.error('Error logging out:', error)
+  }
+  clearTokens()
+  router.push('/login')
+}
+
+// 刷新令牌，并发请求共用同一次刷新
+const refresh = () => {
+  if (!refreshing) {
+    refreshing = (async () => {
+      try {
+        const response = await fetch(`${AUTH_URL}/refresh`, {
+          method: 'POST',
+          headers: {
+            'Content-Type': 'application/json',
+          },
+          body: JSON.stringify({ refresh_token: refreshToken }),
+        })
+        if (!response.ok) {
+          return false
+        }
+        saveTokens(await response.json())
+        return true
+      } finally {
+        refreshing = null
+      }
+    })()
+  }
+  return refreshing
+}
+
+// 带访问令牌的 fetch，令牌过期时自动刷新一次，刷新失败跳转到登录页
+export const apiFetch = async (url, options = {}) => {
+  const send = () =>
+    fetch(url, {
+      ...options,
+      headers: {
+        ...options.headers,
+        Authorization: `Bearer ${accessToken}`,
+      },
+    })
+
+  let response = await send()
+  if (response.status === 401 && refreshToken) {
+    if (await refresh()) {
+      response = await send()
+    }
+  }
+  if (response.status === 401) {
+    clearTokens()
+    router.push('/login')
+  }
+  return response
+}
@@ -2,10 +2,18 @@ import { createRouter, createWebHistory } from 'vue-router'
 import UserList from '../components/UserList.vue'
 import TableManager from '../components/TableManager.vue'
 import RedisManager from '../components/RedisManager.vue'
+import LoginView from '../components/LoginView.vue'
+import { isLoggedIn } from '../auth'
 
 const router = createRouter({
   history: createWebHistory(import.meta.env.BASE_URL),
   routes: [
+    {
+      path: '/login',
+      name: 'login',
+      component: LoginView,
+      meta: { public: true }
+    },
     {
       path: '/',
       name: 'users',
@@ -24,4 +32,11 @@ const router = createRouter({
   ]
 })
 
+// 未登录时跳转到登录页
+router.beforeEach((to) => {
+  if (!to.meta.public && !isLoggedIn()) {
+    return { name: 'login', query: { redirect: to.fullPath } }
+  }
+})
+
 export default router